@@ -0,0 +1,48 @@
+package xin
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptSpec Accept 类请求头中的一项，如 "zh-CN;q=0.8"
+type acceptSpec struct {
+	value string
+	q     float64
+}
+
+// parseAccept 解析 Accept、Accept-Language 等带 q 值的请求头
+// 返回结果按 q 值从高到低排序，q 值相同时保持原有顺序
+// q=0 的项表示明确排除，会保留在结果末尾，使用方需要自行处理
+func parseAccept(header string) []acceptSpec {
+	if header == "" {
+		return nil
+	}
+	specs := make([]acceptSpec, 0, strings.Count(header, ",")+1)
+	for _, item := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(item, ";")
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		spec := acceptSpec{value: value, q: 1}
+		for _, param := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(k), "q") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				spec.q = q
+			}
+		}
+		if spec.q < 0 {
+			spec.q = 0
+		}
+		specs = append(specs, spec)
+	}
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].q > specs[j].q
+	})
+	return specs
+}
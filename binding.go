@@ -1,30 +1,77 @@
 package xin
 
 import (
+	"io"
 	"net/http"
 	"reflect"
 	"strings"
 
 	"github.com/fengjx/go-halo/json"
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/schema"
 )
 
-var decoder = schema.NewDecoder()
-var validate *validator.Validate
+var (
+	decoder       = newDecoder(true)
+	strictDecoder = newDecoder(false)
+)
 
-func init() {
-	decoder.IgnoreUnknownKeys(true)
-	decoder.SetAliasTag("json")
-	decoder.RegisterConverter([]string{}, func(s string) reflect.Value {
+func newDecoder(ignoreUnknownKeys bool) *schema.Decoder {
+	d := schema.NewDecoder()
+	d.IgnoreUnknownKeys(ignoreUnknownKeys)
+	d.SetAliasTag("json")
+	d.RegisterConverter([]string{}, func(s string) reflect.Value {
 		return reflect.ValueOf(strings.Split(s, ","))
 	})
-	validate = validator.New()
-	validate.SetTagName("binding")
+	return d
+}
+
+// BindOptions 参数绑定配置
+type BindOptions struct {
+	// Strict 严格模式，url 参数、表单或 json 中存在结构体未定义的字段时返回错误
+	Strict bool
+	// MaxBodyBytes ShouldBindJSON 读取 body 的最大字节数，超出时返回 *http.MaxBytesError
+	// 0 表示不限制
+	MaxBodyBytes int64
+}
+
+var bindOptions BindOptions
+
+// SetBindOptions 设置全局参数绑定配置，单次调用可以使用 ShouldBindWith、ShouldBindJSONWith 覆盖
+func SetBindOptions(opts BindOptions) {
+	bindOptions = opts
+}
+
+func (opts BindOptions) decoder() *schema.Decoder {
+	if opts.Strict {
+		return strictDecoder
+	}
+	return decoder
 }
 
 // ShouldBind 从参数url参数和form表单解析参数
+// 校验失败时返回 validator.ValidationErrors，可以使用 TranslateError 翻译为可读的错误信息
+// 同时支持通过 path、header、cookie 标签绑定路径参数、请求头和 Cookie
+// multipart/form-data 请求会按 SetMultipartOptions 的全局配置解析，参考 ShouldBindMultipart
+// 请求中没有的字段会使用 default 标签设置默认值
+//
+//	type GetUserReq struct {
+//		ID     int64  `path:"id" binding:"required"`
+//		Tenant string `header:"X-Tenant"`
+//		SID    string `cookie:"sid"`
+//		Size   int    `json:"size" default:"20"`
+//	}
 func ShouldBind(r *http.Request, obj any) error {
+	return ShouldBindWith(r, obj, bindOptions)
+}
+
+// ShouldBindWith 与 ShouldBind 相同，使用 opts 覆盖全局绑定配置
+func ShouldBindWith(r *http.Request, obj any, opts BindOptions) error {
+	if isMultipart(r) {
+		return shouldBindMultipart(r, obj, multipartOptions, opts)
+	}
+	if err := applyDefaults(obj); err != nil {
+		return err
+	}
 	values := r.URL.Query()
 	contentType := r.Header.Get("Content-Type")
 	if contentType == "application/x-www-form-urlencoded" {
@@ -36,20 +83,61 @@ func ShouldBind(r *http.Request, obj any) error {
 			values[key] = val
 		}
 	}
-	err := decoder.Decode(obj, values)
+	err := opts.decoder().Decode(obj, values)
 	if err != nil {
 		return err
 	}
-	return validate.Struct(obj)
+	if err = bindRequestTags(r, obj); err != nil {
+		return err
+	}
+	return structValidator.ValidateStruct(obj)
 }
 
 // ShouldBindJSON 从body解析json
+// path、header、cookie 标签的字段会在解析 body 之后填充，不会使用 json 中的值，json 中没有的字段会使用 default 标签设置默认值
 func ShouldBindJSON(r *http.Request, obj any) error {
-	err := json.NewDecoder(r.Body).Decode(obj)
+	return ShouldBindJSONWith(r, obj, bindOptions)
+}
+
+// ShouldBindJSONWith 与 ShouldBindJSON 相同，使用 opts 覆盖全局绑定配置
+func ShouldBindJSONWith(r *http.Request, obj any, opts BindOptions) error {
+	if err := applyDefaults(obj); err != nil {
+		return err
+	}
+	body := &bodyReader{Reader: r.Body}
+	if opts.MaxBodyBytes > 0 {
+		body.Reader = http.MaxBytesReader(nil, r.Body, opts.MaxBodyBytes)
+	}
+	dec := json.NewDecoder(body)
+	if opts.Strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(obj)
 	if err != nil {
+		if body.err != nil {
+			return body.err
+		}
 		return err
 	}
-	return validate.Struct(obj)
+	if err = bindRequestTags(r, obj); err != nil {
+		return err
+	}
+	return structValidator.ValidateStruct(obj)
+}
+
+// bodyReader 记录读取 body 时发生的错误
+// json 解析器会把读取错误转换为字符串，这里保留原始错误，如 *http.MaxBytesError
+type bodyReader struct {
+	io.Reader
+	err error
+}
+
+func (br *bodyReader) Read(p []byte) (int, error) {
+	n, err := br.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		br.err = err
+	}
+	return n, err
 }
 
 // GetQuery 获取URL查询参数，如果参数不存在返回空字符串
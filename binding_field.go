@@ -0,0 +1,250 @@
+package xin
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pathTag    = "path"
+	headerTag  = "header"
+	cookieTag  = "cookie"
+	defaultTag = "default"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// bindRequestTags 根据 path、header、cookie 标签从请求中填充结构体字段
+// 这些字段只能来自对应的请求值，body 和 url 参数中的同名字段会被丢弃，请求中没有时重置为零值或 default 标签的值
+func bindRequestTags(r *http.Request, obj any) error {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	return bindStructTags(r, rv)
+}
+
+func bindStructTags(r *http.Request, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous && indirectType(sf.Type).Kind() == reflect.Struct {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						continue
+					}
+					// 嵌入的空指针只在有字段被绑定时才赋值
+					nv := reflect.New(sf.Type.Elem())
+					if err := bindStructTags(r, nv.Elem()); err != nil {
+						return err
+					}
+					if !nv.Elem().IsZero() {
+						fv.Set(nv)
+					}
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if err := bindStructTags(r, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() || !hasRequestTag(sf) {
+			continue
+		}
+		values, ok := requestTagValues(r, sf)
+		if !ok {
+			// 避免通过 body 或 url 参数伪造，如租户 id
+			fv.SetZero()
+			if def, ok := sf.Tag.Lookup(defaultTag); ok {
+				if err := setWithString(fv, def); err != nil {
+					return fmt.Errorf("xin: default value of field %s: %w", sf.Name, err)
+				}
+			}
+			continue
+		}
+		if err := setFieldValues(fv, values); err != nil {
+			return fmt.Errorf("xin: bind field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyDefaults 为零值字段设置 default 标签中的默认值
+// 在解析请求参数之前调用，请求中存在的字段会覆盖默认值
+func applyDefaults(obj any) error {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	return applyStructDefaults(rv)
+}
+
+func applyStructDefaults(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		fv := rv.Field(i)
+		def, ok := sf.Tag.Lookup(defaultTag)
+		if !ok || !sf.IsExported() {
+			if fv.Kind() == reflect.Struct && !fv.Addr().Type().Implements(textUnmarshalerType) {
+				if err := applyStructDefaults(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !fv.IsZero() {
+			continue
+		}
+		if err := setWithString(fv, def); err != nil {
+			return fmt.Errorf("xin: default value of field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+// requestTagValues 按 path、header、cookie 的顺序查找字段对应的请求值
+func requestTagValues(r *http.Request, sf reflect.StructField) ([]string, bool) {
+	if name := tagName(sf, pathTag); name != "" {
+		if val := r.PathValue(name); val != "" {
+			return []string{val}, true
+		}
+	}
+	if name := tagName(sf, headerTag); name != "" {
+		if vals := r.Header.Values(name); len(vals) > 0 {
+			return vals, true
+		}
+	}
+	if name := tagName(sf, cookieTag); name != "" {
+		if c, err := r.Cookie(name); err == nil {
+			return []string{c.Value}, true
+		}
+	}
+	return nil, false
+}
+
+// hasRequestTag 判断字段是否有 path、header、cookie 标签
+func hasRequestTag(sf reflect.StructField) bool {
+	return tagName(sf, pathTag) != "" || tagName(sf, headerTag) != "" || tagName(sf, cookieTag) != ""
+}
+
+// tagName 返回标签中的名称部分，忽略 "," 之后的选项
+func tagName(sf reflect.StructField, key string) string {
+	tag := sf.Tag.Get(key)
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// setFieldValues 将字符串值写入字段，切片类型会逐个转换
+func setFieldValues(fv reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if fv.Kind() == reflect.Slice && !fv.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(fv.Type(), 0, len(values))
+		for _, val := range values {
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if err := setWithString(ev, val); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, ev)
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setWithString(fv, values[0])
+}
+
+// setWithString 将字符串转换为字段类型后写入
+func setWithString(fv reflect.Value, val string) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setWithString(fv.Elem(), val)
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		if val == "" {
+			fv.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		return setFieldValues(fv, strings.Split(val, ","))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
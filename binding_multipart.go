@@ -0,0 +1,345 @@
+package xin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	// DefaultMultipartMemory 默认的 multipart 内存上限，超出部分写入临时文件
+	DefaultMultipartMemory = 32 << 20
+	sniffLen               = 512
+	// 与 mime/multipart 一致，非文件字段额外允许 10MB 内存，最多 1000 个 part
+	multipartValueMemory = 10 << 20
+	multipartMaxParts    = 1000
+)
+
+var (
+	// ErrFileTooLarge 单个上传文件超出大小限制
+	ErrFileTooLarge = errors.New("xin: multipart file too large")
+	// ErrMultipartTooLarge 上传内容总大小超出限制
+	ErrMultipartTooLarge = errors.New("xin: multipart body too large")
+	// ErrFileTypeNotAllowed 上传文件类型不在允许列表中
+	ErrFileTypeNotAllowed = errors.New("xin: multipart file type not allowed")
+)
+
+var (
+	uploadFileType      = reflect.TypeOf((*UploadFile)(nil))
+	uploadFileSliceType = reflect.TypeOf([]*UploadFile(nil))
+)
+
+// MultipartOptions multipart/form-data 解析配置
+type MultipartOptions struct {
+	// MaxMemory 内存中保存的最大字节数，超出的文件写入 TempDir 下的临时文件
+	// 临时文件会在请求结束时自动清理，默认 DefaultMultipartMemory
+	MaxMemory int64
+	// TempDir 临时文件目录，为空时使用 os.TempDir()
+	TempDir string
+	// MaxFileSize 单个文件的最大字节数，0 表示不限制
+	MaxFileSize int64
+	// MaxTotalSize 所有 part 的总字节数上限，0 表示不限制
+	MaxTotalSize int64
+	// AllowedTypes 允许上传的文件类型，根据文件内容嗅探，支持 "image/*" 形式的通配
+	// 为空表示不限制
+	AllowedTypes []string
+}
+
+var multipartOptions = MultipartOptions{
+	MaxMemory: DefaultMultipartMemory,
+}
+
+// SetMultipartOptions 设置全局 multipart 解析配置
+func SetMultipartOptions(opts MultipartOptions) {
+	if opts.MaxMemory <= 0 {
+		opts.MaxMemory = DefaultMultipartMemory
+	}
+	multipartOptions = opts
+}
+
+// ShouldBindMultipart 使用自定义配置解析 multipart/form-data 请求
+// 支持 *UploadFile 和 []*UploadFile 类型的字段，字段名与 json 标签一致
+func ShouldBindMultipart(r *http.Request, obj any, opts MultipartOptions) error {
+	return shouldBindMultipart(r, obj, opts, bindOptions)
+}
+
+func shouldBindMultipart(r *http.Request, obj any, opts MultipartOptions, bindOpts BindOptions) error {
+	if opts.MaxMemory <= 0 {
+		opts.MaxMemory = DefaultMultipartMemory
+	}
+	if err := applyDefaults(obj); err != nil {
+		return err
+	}
+	form, err := parseMultipartForm(r, opts)
+	if err != nil {
+		return err
+	}
+	values := r.URL.Query()
+	for key, val := range form.Value {
+		values[key] = append(values[key], val...)
+	}
+	if err = bindOpts.decoder().Decode(obj, values); err != nil {
+		return err
+	}
+	if err = bindFormFiles(obj, form.File); err != nil {
+		return err
+	}
+	if err = bindRequestTags(r, obj); err != nil {
+		return err
+	}
+	return structValidator.ValidateStruct(obj)
+}
+
+// SaveUploadedFile 将上传的文件保存到 dst，目标目录不存在时自动创建
+func SaveUploadedFile(file *UploadFile, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parseMultipartForm 解析 multipart 表单，并在请求结束时清理临时文件
+// 请求 body 会先经过大小和类型检查，超出 MaxMemory 的文件写入 TempDir
+// 解析后 r.Body 会被替换，重复绑定时使用已经解析的结果
+func parseMultipartForm(r *http.Request, opts MultipartOptions) (*uploadForm, error) {
+	if form, ok := parsedUploadForm(r); ok {
+		return form, nil
+	}
+	if r.Form == nil {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	form := newUploadForm()
+	if err = copyParts(form, mr, opts); err != nil {
+		_ = form.removeAll()
+		return nil, err
+	}
+	// 请求结束后 context 会被取消，此时删除临时文件
+	context.AfterFunc(r.Context(), func() {
+		_ = form.removeAll()
+	})
+
+	if r.PostForm == nil {
+		r.PostForm = make(map[string][]string)
+	}
+	for key, val := range form.Value {
+		r.Form[key] = append(r.Form[key], val...)
+		r.PostForm[key] = append(r.PostForm[key], val...)
+	}
+	r.Body = parsedBody{form: form}
+	return form, nil
+}
+
+// copyParts 逐个读取 part 并检查大小和文件类型，通过检查的 part 写入 form
+// 文件超出剩余的 MaxMemory 时写入 TempDir 下的临时文件
+func copyParts(form *uploadForm, mr *multipart.Reader, opts MultipartOptions) error {
+	var total int64
+	memory := opts.MaxMemory
+	valueMemory := opts.MaxMemory + multipartValueMemory
+	for parts := 0; ; parts++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if parts >= multipartMaxParts {
+			return multipart.ErrMessageTooLarge
+		}
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+		limit := int64(-1)
+		if opts.MaxTotalSize > 0 {
+			limit = opts.MaxTotalSize - total
+		}
+		isFile := part.FileName() != ""
+		if isFile && opts.MaxFileSize > 0 && (limit < 0 || opts.MaxFileSize < limit) {
+			limit = opts.MaxFileSize
+		}
+
+		var src io.Reader = part
+		if isFile && len(opts.AllowedTypes) > 0 {
+			br := bufio.NewReaderSize(part, sniffLen)
+			head, err := br.Peek(sniffLen)
+			if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+				return err
+			}
+			contentType := http.DetectContentType(head)
+			if !matchContentType(contentType, opts.AllowedTypes) {
+				return fmt.Errorf("%w: %s(%s)", ErrFileTypeNotAllowed, part.FileName(), contentType)
+			}
+			src = br
+		}
+		if limit >= 0 {
+			src = io.LimitReader(src, limit+1)
+		}
+
+		var n int64
+		if isFile {
+			n, err = copyFilePart(form, name, part, src, memory, opts.TempDir)
+			if err == nil && n <= memory {
+				memory -= n
+			}
+		} else {
+			var sb strings.Builder
+			n, err = io.Copy(&sb, io.LimitReader(src, valueMemory+1))
+			valueMemory -= n
+			if err == nil && valueMemory < 0 {
+				err = multipart.ErrMessageTooLarge
+			}
+			form.Value[name] = append(form.Value[name], sb.String())
+		}
+		if err != nil {
+			return err
+		}
+		total += n
+		if opts.MaxTotalSize > 0 && total > opts.MaxTotalSize {
+			return ErrMultipartTooLarge
+		}
+		if isFile && opts.MaxFileSize > 0 && n > opts.MaxFileSize {
+			return fmt.Errorf("%w: %s", ErrFileTooLarge, part.FileName())
+		}
+	}
+}
+
+// copyFilePart 读取文件 part，不超过 memory 时保存在内存中，否则写入 tempDir 下的临时文件
+func copyFilePart(form *uploadForm, name string, part *multipart.Part, src io.Reader, memory int64, tempDir string) (int64, error) {
+	f := &UploadFile{
+		Filename: part.FileName(),
+		Header:   part.Header,
+	}
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, src, memory+1)
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	if n <= memory {
+		f.content = buf.Bytes()
+		f.Size = n
+		form.File[name] = append(form.File[name], f)
+		return n, nil
+	}
+
+	file, err := os.CreateTemp(tempDir, "multipart-")
+	if err != nil {
+		return n, err
+	}
+	// 先加入 form，出错时由 removeAll 删除临时文件
+	f.tmpfile = file.Name()
+	form.File[name] = append(form.File[name], f)
+	n, err = io.Copy(file, io.MultiReader(&buf, src))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	f.Size = n
+	return n, err
+}
+
+// matchContentType 判断 contentType 是否在 allowed 中，支持 "image/*" 形式的通配
+func matchContentType(contentType string, allowed []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	for _, t := range allowed {
+		if t == "*/*" || strings.EqualFold(t, mediaType) {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bindFormFiles 将上传文件绑定到 *UploadFile 和 []*UploadFile 类型的字段
+func bindFormFiles(obj any, files map[string][]*UploadFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	bindStructFiles(rv.Elem(), files)
+	return nil
+}
+
+func bindStructFiles(rv reflect.Value, files map[string][]*UploadFile) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous && indirectType(sf.Type).Kind() == reflect.Struct {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						continue
+					}
+					// 嵌入的空指针只在有文件被绑定时才赋值
+					nv := reflect.New(sf.Type.Elem())
+					bindStructFiles(nv.Elem(), files)
+					if !nv.Elem().IsZero() {
+						fv.Set(nv)
+					}
+					continue
+				}
+				fv = fv.Elem()
+			}
+			bindStructFiles(fv, files)
+			continue
+		}
+		if !sf.IsExported() || (sf.Type != uploadFileType && sf.Type != uploadFileSliceType) {
+			continue
+		}
+		fhs := files[jsonFieldName(sf)]
+		if len(fhs) == 0 {
+			continue
+		}
+		if sf.Type == uploadFileType {
+			fv.Set(reflect.ValueOf(fhs[0]))
+		} else {
+			fv.Set(reflect.ValueOf(fhs))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package xin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"reflect"
+)
+
+// ErrStreamTooManyItems 流式解析的元素数量超出限制
+var ErrStreamTooManyItems = errors.New("xin: stream has too many items")
+
+// StreamOptions 流式解析配置
+type StreamOptions struct {
+	// MaxItems 最多解析的元素数量，超出时返回 ErrStreamTooManyItems，0 表示不限制
+	MaxItems int
+	// Strict 严格模式，元素中存在结构体未定义的字段时返回错误
+	Strict bool
+}
+
+// StreamItemError 流式解析中单个元素的错误
+type StreamItemError struct {
+	// Index 元素的序号，从 0 开始
+	Index int
+	Err   error
+}
+
+func (e *StreamItemError) Error() string {
+	return fmt.Sprintf("xin: stream item %d: %v", e.Index, e.Err)
+}
+
+func (e *StreamItemError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeStream 流式解析 NDJSON 或 json 数组格式的请求 body，不会一次性读取整个 body
+//
+//	for item, err := range xin.DecodeStream[Order](r) {
+//		if err != nil {
+//			// 处理错误
+//			break
+//		}
+//	}
+//
+// 结构体元素会设置 default 标签的默认值并使用 binding 标签校验
+// 校验失败时返回 *StreamItemError，迭代可以继续；body 格式错误或超出数量限制时迭代结束
+func DecodeStream[T any](r *http.Request) iter.Seq2[T, error] {
+	return DecodeStreamWith[T](r, StreamOptions{})
+}
+
+// DecodeStreamWith 与 DecodeStream 相同，可以设置流式解析配置
+func DecodeStreamWith[T any](r *http.Request, opts StreamOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		br := bufio.NewReader(r.Body)
+		first, err := peekNonSpace(br)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		dec := json.NewDecoder(br)
+		if opts.Strict {
+			dec.DisallowUnknownFields()
+		}
+		isArray := first == '['
+		if isArray {
+			// 读取 '['
+			if _, err = dec.Token(); err != nil {
+				yield(zero, err)
+				return
+			}
+		}
+		typ := reflect.TypeFor[T]()
+		doValidate := indirectType(typ).Kind() == reflect.Struct
+
+		for i := 0; ; i++ {
+			if isArray && !dec.More() {
+				if _, err = dec.Token(); err != nil {
+					yield(zero, err)
+				}
+				return
+			}
+			if opts.MaxItems > 0 && i >= opts.MaxItems {
+				if isArray || dec.More() {
+					yield(zero, ErrStreamTooManyItems)
+				}
+				return
+			}
+			var item T
+			var target any = &item
+			if typ.Kind() == reflect.Pointer {
+				// T 为指针时先分配，默认值设置在指向的值上
+				reflect.ValueOf(&item).Elem().Set(reflect.New(typ.Elem()))
+				target = item
+			}
+			if err = applyDefaults(target); err != nil {
+				yield(zero, err)
+				return
+			}
+			err = dec.Decode(&item)
+			if err == io.EOF && !isArray {
+				return
+			}
+			if err != nil {
+				yield(zero, &StreamItemError{Index: i, Err: err})
+				return
+			}
+			if doValidate {
+				var obj any = &item
+				if typ.Kind() == reflect.Pointer {
+					obj = item
+				}
+				if err = structValidator.ValidateStruct(obj); err != nil {
+					err = &StreamItemError{Index: i, Err: err}
+				}
+			}
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
+
+// peekNonSpace 跳过空白字符，返回第一个非空白字符但不消费它
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
@@ -0,0 +1,99 @@
+package xin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+type streamItem struct {
+	ID   int    `json:"id" binding:"required"`
+	Type string `json:"type" default:"normal"`
+}
+
+func TestDecodeStream(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "ndjson",
+			body: "{\"id\":1}\n{\"id\":2,\"type\":\"vip\"}\n\n{\"id\":3}\n",
+		},
+		{
+			name: "json array",
+			body: ` [{"id":1}, {"id":2,"type":"vip"}, {"id":3}] `,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			var items []streamItem
+			for item, err := range xin.DecodeStream[streamItem](r) {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				items = append(items, item)
+			}
+			if len(items) != 3 {
+				t.Fatalf("expected 3 items, got %d", len(items))
+			}
+			if items[0].Type != "normal" || items[1].Type != "vip" || items[2].ID != 3 {
+				t.Errorf("unexpected items: %+v", items)
+			}
+		})
+	}
+}
+
+func TestDecodeStreamPointer(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"id":1},{"id":2,"type":"vip"}]`))
+	var items []*streamItem
+	for item, err := range xin.DecodeStream[*streamItem](r) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		items = append(items, item)
+	}
+	if len(items) != 2 || items[0].Type != "normal" || items[1].Type != "vip" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+}
+
+func TestDecodeStreamErrors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"id":1},{"type":"x"},{"id":3}]`))
+	var count int
+	var itemErr *xin.StreamItemError
+	for _, err := range xin.DecodeStream[streamItem](r) {
+		count++
+		if err != nil && !errors.As(err, &itemErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if count != 3 || itemErr == nil || itemErr.Index != 1 {
+		t.Errorf("expected validation error on item 1, got count=%d err=%v", count, itemErr)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{\"id\":1}\n{\"id\":2}\n{\"id\":3}"))
+	var lastErr error
+	count = 0
+	for _, err := range xin.DecodeStreamWith[streamItem](r, xin.StreamOptions{MaxItems: 2}) {
+		count++
+		lastErr = err
+	}
+	if count != 3 || !errors.Is(lastErr, xin.ErrStreamTooManyItems) {
+		t.Errorf("expected ErrStreamTooManyItems, got count=%d err=%v", count, lastErr)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"id":1},{"id":`))
+	lastErr = nil
+	for _, err := range xin.DecodeStream[*streamItem](r) {
+		lastErr = err
+	}
+	if lastErr == nil {
+		t.Error("expected syntax error")
+	}
+}
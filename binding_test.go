@@ -0,0 +1,338 @@
+package xin_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+func TestShouldBindRequestTags(t *testing.T) {
+	type req struct {
+		ID     int64    `path:"id" binding:"required"`
+		Tenant string   `header:"X-Tenant"`
+		Tags   []string `header:"X-Tag"`
+		SID    string   `cookie:"sid"`
+		Name   string   `json:"name"`
+	}
+
+	var got req
+	var bindErr error
+	mux := xin.NewMux()
+	mux.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		bindErr = xin.ShouldBind(r, &got)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42?name=foo", nil)
+	r.Header.Set("X-Tenant", "t1")
+	r.Header.Add("X-Tag", "a")
+	r.Header.Add("X-Tag", "b")
+	r.AddCookie(&http.Cookie{Name: "sid", Value: "s-1"})
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if bindErr != nil {
+		t.Fatalf("unexpected error: %v", bindErr)
+	}
+	if got.ID != 42 || got.Tenant != "t1" || got.SID != "s-1" || got.Name != "foo" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "a" || got.Tags[1] != "b" {
+		t.Errorf("unexpected tags: %v", got.Tags)
+	}
+}
+
+func TestShouldBindRequestTagsNotSpoofed(t *testing.T) {
+	type req struct {
+		ID     int64  `path:"id"`
+		Tenant string `header:"X-Tenant"`
+		Role   string `header:"X-Role" default:"guest"`
+		SID    string `cookie:"sid"`
+		Name   string `json:"name"`
+	}
+
+	t.Run("query", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users?ID=9&Tenant=evil&Role=admin&SID=s-evil&name=foo", nil)
+		var got req
+		if err := xin.ShouldBind(r, &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != (req{Role: "guest", Name: "foo"}) {
+			t.Errorf("request tag fields set from query: %+v", got)
+		}
+	})
+
+	t.Run("json body", func(t *testing.T) {
+		body := `{"ID":9,"Tenant":"evil","Role":"admin","SID":"s-evil","name":"foo"}`
+		r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		var got req
+		if err := xin.ShouldBindJSON(r, &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != (req{Role: "guest", Name: "foo"}) {
+			t.Errorf("request tag fields set from body: %+v", got)
+		}
+	})
+}
+
+func TestShouldBindJSONRequestTags(t *testing.T) {
+	type req struct {
+		ID   int64  `path:"id" binding:"required"`
+		Name string `json:"name" binding:"required"`
+	}
+
+	var got req
+	var bindErr error
+	mux := xin.NewMux()
+	mux.PUT("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		bindErr = xin.ShouldBindJSON(r, &got)
+	})
+
+	r := httptest.NewRequest(http.MethodPut, "/users/7", strings.NewReader(`{"name":"bar"}`))
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if bindErr != nil {
+		t.Fatalf("unexpected error: %v", bindErr)
+	}
+	if got.ID != 7 || got.Name != "bar" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+
+	r = httptest.NewRequest(http.MethodPut, "/users/abc", strings.NewReader(`{"name":"bar"}`))
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if bindErr == nil {
+		t.Error("expected error for invalid path value")
+	}
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string][]byte) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile(name, name+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = fw.Write(content)
+	}
+	_ = mw.Close()
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestShouldBindMultipart(t *testing.T) {
+	type req struct {
+		Title  string          `json:"title" binding:"required"`
+		Avatar *xin.UploadFile `json:"avatar" binding:"required"`
+	}
+
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), make([]byte, 64)...)
+	r := newMultipartRequest(t, map[string]string{"title": "hello"}, map[string][]byte{"avatar": png})
+	var got req
+	if err := xin.ShouldBind(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Title != "hello" || got.Avatar == nil || got.Avatar.Size != int64(len(png)) {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+
+	dst := filepath.Join(t.TempDir(), "avatar", "a.png")
+	if err := xin.SaveUploadedFile(got.Avatar, dst); err != nil {
+		t.Fatalf("save uploaded file: %v", err)
+	}
+	if data, _ := os.ReadFile(dst); !bytes.Equal(data, png) {
+		t.Error("saved file content mismatch")
+	}
+
+	tests := []struct {
+		name    string
+		opts    xin.MultipartOptions
+		content []byte
+		wantErr error
+	}{
+		{
+			name:    "file too large",
+			opts:    xin.MultipartOptions{MaxFileSize: 16},
+			content: png,
+			wantErr: xin.ErrFileTooLarge,
+		},
+		{
+			name:    "body too large",
+			opts:    xin.MultipartOptions{MaxTotalSize: 32},
+			content: png,
+			wantErr: xin.ErrMultipartTooLarge,
+		},
+		{
+			name:    "type not allowed",
+			opts:    xin.MultipartOptions{AllowedTypes: []string{"image/*"}},
+			content: []byte("plain text"),
+			wantErr: xin.ErrFileTypeNotAllowed,
+		},
+		{
+			name:    "type allowed",
+			opts:    xin.MultipartOptions{AllowedTypes: []string{"image/*"}, MaxFileSize: 1024},
+			content: png,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newMultipartRequest(t, map[string]string{"title": "hello"}, map[string][]byte{"avatar": tt.content})
+			var got req
+			err := xin.ShouldBindMultipart(r, &got, tt.opts)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestShouldBindMultipartTempDir(t *testing.T) {
+	type Attachment struct {
+		Attachment *xin.UploadFile `json:"attachment"`
+	}
+	type req struct {
+		*Attachment
+		Title  string          `json:"title"`
+		Avatar *xin.UploadFile `json:"avatar"`
+	}
+
+	dir := t.TempDir()
+	large := bytes.Repeat([]byte("a"), 1024)
+	r := newMultipartRequest(t, map[string]string{"title": "hello"}, map[string][]byte{
+		"avatar":     large,
+		"attachment": []byte("small"),
+	})
+	var got req
+	if err := xin.ShouldBindMultipart(r, &got, xin.MultipartOptions{MaxMemory: 512, TempDir: dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Attachment == nil || got.Attachment.Attachment == nil {
+		t.Fatal("expected file bound to embedded pointer struct")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 spilled file in TempDir, got %d", len(entries))
+	}
+	for fh, want := range map[*xin.UploadFile][]byte{got.Avatar: large, got.Attachment.Attachment: []byte("small")} {
+		f, err := fh.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(f)
+		_ = f.Close()
+		if fh.Size != int64(len(want)) || !bytes.Equal(data, want) {
+			t.Errorf("unexpected content of %s: %d bytes", fh.Filename, len(data))
+		}
+	}
+
+	// 重复绑定使用已经解析的表单
+	var again req
+	if err := xin.ShouldBindMultipart(r, &again, xin.MultipartOptions{MaxMemory: 512, TempDir: dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.Title != "hello" || again.Avatar != got.Avatar {
+		t.Errorf("unexpected result of second bind: %+v", again)
+	}
+}
+
+func TestShouldBindParsedMultipartForm(t *testing.T) {
+	type req struct {
+		Title  string          `json:"title"`
+		Avatar *xin.UploadFile `json:"avatar"`
+	}
+	r := newMultipartRequest(t, map[string]string{"title": "hello"}, map[string][]byte{"avatar": []byte("png")})
+	if err := r.ParseMultipartForm(xin.DefaultMultipartMemory); err != nil {
+		t.Fatal(err)
+	}
+	var got req
+	if err := xin.ShouldBind(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Title != "hello" || got.Avatar == nil || got.Avatar.Size != 3 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+	f, err := got.Avatar.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if data, _ := io.ReadAll(f); string(data) != "png" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestShouldBindDefault(t *testing.T) {
+	type page struct {
+		Page int `json:"page" default:"1"`
+		Size int `json:"size" default:"20"`
+	}
+	type req struct {
+		page
+		Sort  []string `json:"sort" default:"id,name"`
+		Debug bool     `json:"debug" default:"true"`
+	}
+
+	var got req
+	r := httptest.NewRequest(http.MethodGet, "/?size=50", nil)
+	if err := xin.ShouldBind(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Page != 1 || got.Size != 50 || !got.Debug || len(got.Sort) != 2 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+
+	got = req{}
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"page":3,"debug":false}`))
+	if err := xin.ShouldBindJSON(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Page != 3 || got.Size != 20 || got.Debug {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestShouldBindStrict(t *testing.T) {
+	type req struct {
+		Name string `json:"name"`
+	}
+	strict := xin.BindOptions{Strict: true}
+
+	r := httptest.NewRequest(http.MethodGet, "/?name=foo&age=1", nil)
+	if err := xin.ShouldBind(r, &req{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	r = httptest.NewRequest(http.MethodGet, "/?name=foo&age=1", nil)
+	if err := xin.ShouldBindWith(r, &req{}, strict); err == nil {
+		t.Error("expected error for unknown query key")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"foo","age":1}`))
+	if err := xin.ShouldBindJSONWith(r, &req{}, strict); err == nil {
+		t.Error("expected error for unknown json field")
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"foobarbaz"}`))
+	err := xin.ShouldBindJSONWith(r, &req{}, xin.BindOptions{MaxBodyBytes: 8})
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		t.Errorf("expected MaxBytesError, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package xin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+)
+
+// UploadFile multipart/form-data 请求中上传的文件
+// 不超过 MaxMemory 的文件保存在内存中，超出时保存在 MultipartOptions.TempDir 下的临时文件，请求结束时自动删除
+type UploadFile struct {
+	Filename string
+	Header   textproto.MIMEHeader
+	Size     int64
+
+	content []byte
+	tmpfile string
+	// fh 请求已经由 http.Request.ParseMultipartForm 解析时使用
+	fh *multipart.FileHeader
+}
+
+// Open 打开上传的文件，使用后需要关闭
+func (f *UploadFile) Open() (multipart.File, error) {
+	switch {
+	case f.fh != nil:
+		return f.fh.Open()
+	case f.tmpfile != "":
+		return os.Open(f.tmpfile)
+	default:
+		return bytesFile{bytes.NewReader(f.content)}, nil
+	}
+}
+
+// bytesFile 内存中的文件
+type bytesFile struct {
+	*bytes.Reader
+}
+
+func (bytesFile) Close() error {
+	return nil
+}
+
+// uploadForm 解析后的 multipart 表单
+type uploadForm struct {
+	Value map[string][]string
+	File  map[string][]*UploadFile
+}
+
+func newUploadForm() *uploadForm {
+	return &uploadForm{
+		Value: make(map[string][]string),
+		File:  make(map[string][]*UploadFile),
+	}
+}
+
+// fromMultipartForm 使用 http.Request.ParseMultipartForm 的解析结果
+func fromMultipartForm(mf *multipart.Form) *uploadForm {
+	form := newUploadForm()
+	form.Value = mf.Value
+	for name, fhs := range mf.File {
+		for _, fh := range fhs {
+			form.File[name] = append(form.File[name], &UploadFile{
+				Filename: fh.Filename,
+				Header:   fh.Header,
+				Size:     fh.Size,
+				fh:       fh,
+			})
+		}
+	}
+	return form
+}
+
+// removeAll 删除临时文件
+func (form *uploadForm) removeAll() error {
+	var errs []error
+	for _, files := range form.File {
+		for _, f := range files {
+			if f.tmpfile == "" {
+				continue
+			}
+			if err := os.Remove(f.tmpfile); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parsedBody 替换已经解析过的请求 body，保存解析结果，重复绑定时直接使用
+type parsedBody struct {
+	form *uploadForm
+}
+
+func (parsedBody) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (parsedBody) Close() error {
+	return nil
+}
+
+// parsedUploadForm 返回已经解析过的表单
+func parsedUploadForm(r *http.Request) (*uploadForm, bool) {
+	if body, ok := r.Body.(parsedBody); ok {
+		return body.form, true
+	}
+	if r.MultipartForm != nil {
+		return fromMultipartForm(r.MultipartForm), true
+	}
+	return nil, false
+}
@@ -3,6 +3,7 @@ package xin
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
 )
 
 // errorKey 是用于在 context 中存储错误的键类型
@@ -44,3 +45,71 @@ func WithErrRequest(r *http.Request, err error) *http.Request {
 func CtxRequestErr(r *http.Request) error {
 	return CtxError(r.Context())
 }
+
+// requestIDFunc 从 context 获取请求 ID，引入 middleware 包时会设置为 middleware.GetReqID
+var requestIDFunc = func(ctx context.Context) string {
+	return ""
+}
+
+// SetRequestIDFunc 设置从 context 获取请求 ID 的函数
+// middleware 包引入 xin，xin 不能直接调用 middleware.GetReqID，middleware 包初始化时会调用此函数
+func SetRequestIDFunc(fn func(ctx context.Context) string) {
+	requestIDFunc = fn
+}
+
+// RequestID 返回 context 中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	return requestIDFunc(ctx)
+}
+
+type routeKey struct{}
+
+// routeRecorder 记录交给 http.ServeMux 路由的请求，ServeMux 会在这个请求上设置 Pattern
+type routeRecorder struct {
+	r atomic.Pointer[http.Request]
+}
+
+// WithRouteRecorder 返回可以记录路由请求的请求，Mux 路由匹配后可以通过 RoutedRequest 获取
+// 用于在路由之前执行的中间件中获取路由信息，如访问日志
+// 没有经过 Mux 时记录的是最后一次传入的请求，已经可以记录时只更新记录的请求
+func WithRouteRecorder(r *http.Request) *http.Request {
+	ctx, rec := withRouteRecorder(r.Context())
+	if ctx != r.Context() {
+		r = r.WithContext(ctx)
+	}
+	rec.r.Store(r)
+	return r
+}
+
+// withRouteRecorder 返回 context 中的路由记录，没有时添加一个
+func withRouteRecorder(ctx context.Context) (context.Context, *routeRecorder) {
+	if rec, ok := ctx.Value(routeKey{}).(*routeRecorder); ok {
+		return ctx, rec
+	}
+	rec := &routeRecorder{}
+	return context.WithValue(ctx, routeKey{}, rec), rec
+}
+
+// RoutedRequest 返回交给 http.ServeMux 路由的请求，可以获取 Pattern 和路由之前中间件添加到 context 中的值
+// 没有调用 WithRouteRecorder 时返回 nil
+func RoutedRequest(ctx context.Context) *http.Request {
+	if rec, ok := ctx.Value(routeKey{}).(*routeRecorder); ok {
+		return rec.r.Load()
+	}
+	return nil
+}
+
+// RoutePattern 返回匹配的路由，如 "GET /users/{id}"，没有匹配或没有调用 WithRouteRecorder 时返回空字符串
+func RoutePattern(ctx context.Context) string {
+	if r := RoutedRequest(ctx); r != nil {
+		return r.Pattern
+	}
+	return ""
+}
+
+// recordRoute 记录交给 http.ServeMux 路由的请求
+func recordRoute(r *http.Request) {
+	if rec, ok := r.Context().Value(routeKey{}).(*routeRecorder); ok {
+		rec.r.Store(r)
+	}
+}
@@ -0,0 +1,200 @@
+package xin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DownloadOptions 文件下载配置
+type DownloadOptions struct {
+	// Filename 下载文件名，设置后写入 Content-Disposition，非 ASCII 文件名按 RFC 6266 编码
+	Filename string
+	// Inline 使用 inline 代替 attachment，浏览器可以直接预览
+	Inline bool
+	// ContentType 为空时根据文件扩展名或内容判断
+	ContentType string
+	// ETag 用于 If-None-Match 和 If-Range 判断，需要包含引号，如 `"v1"` 或 `W/"v1"`
+	ETag string
+	// RateLimit 下载限速，单位字节/秒，0 表示不限速
+	RateLimit int64
+}
+
+// Attachment 以附件形式下载内容，支持 Range 请求和条件请求
+// modtime 不为零值时用于 Last-Modified、If-Modified-Since 和 If-Range 判断
+//
+//	f, _ := os.Open("report.xlsx")
+//	defer f.Close()
+//	xin.Attachment(w, r, "月度报表.xlsx", f, stat.ModTime())
+func Attachment(w http.ResponseWriter, r *http.Request, name string, content io.ReadSeeker, modtime time.Time) {
+	ServeContent(w, r, content, modtime, DownloadOptions{Filename: name})
+}
+
+// FileFromFS 返回 fsys 中的文件，支持 Range 请求和条件请求，不会列出目录
+// 文件不存在时返回 404
+func FileFromFS(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	FileFromFSWith(w, r, fsys, name, DownloadOptions{})
+}
+
+// FileFromFSWith 与 FileFromFS 相同，可以设置下载配置
+// 没有设置 ETag 时根据文件大小和修改时间生成弱 ETag
+func FileFromFSWith(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, opts DownloadOptions) {
+	name = strings.TrimPrefix(name, "/")
+	f, err := fsys.Open(name)
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	if stat.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "file does not support seeking", http.StatusInternalServerError)
+		return
+	}
+	if opts.ETag == "" && !stat.ModTime().IsZero() {
+		opts.ETag = fmt.Sprintf(`W/"%x-%x"`, stat.Size(), stat.ModTime().UnixNano())
+	}
+	serveContent(w, r, stat.Name(), rs, stat.ModTime(), opts)
+}
+
+// ServeContent 返回内容，支持单个和多个 Range、If-Range、If-None-Match、If-Modified-Since
+// 参考 http.ServeContent
+func ServeContent(w http.ResponseWriter, r *http.Request, content io.ReadSeeker, modtime time.Time, opts DownloadOptions) {
+	serveContent(w, r, opts.Filename, content, modtime, opts)
+}
+
+// serveContent name 用于根据扩展名判断 Content-Type
+func serveContent(w http.ResponseWriter, r *http.Request, name string, content io.ReadSeeker, modtime time.Time, opts DownloadOptions) {
+	header := w.Header()
+	if opts.Filename != "" {
+		name = opts.Filename
+		header.Set("Content-Disposition", ContentDisposition(opts.Filename, opts.Inline))
+	}
+	if opts.ContentType != "" {
+		header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.ETag != "" {
+		header.Set("ETag", opts.ETag)
+	}
+	if opts.RateLimit > 0 {
+		content = &throttledReader{
+			ReadSeeker: content,
+			ctx:        r.Context(),
+			rate:       opts.RateLimit,
+		}
+	}
+	http.ServeContent(w, r, name, modtime, content)
+}
+
+// ContentDisposition 生成 Content-Disposition 响应头，参考 RFC 6266
+// 非 ASCII 文件名同时写入 ASCII 兼容的 filename 和 UTF-8 编码的 filename*
+func ContentDisposition(filename string, inline bool) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	filename = strings.NewReplacer("/", "_", "\\", "_").Replace(filename)
+	fallback, ascii := asciiFilename(filename)
+	v := disposition + `; filename="` + fallback + `"`
+	if !ascii {
+		v += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return v
+}
+
+// asciiFilename 将非 ASCII 字符和引号替换为 "_"，返回原文件名是否都是可以直接使用的字符
+func asciiFilename(name string) (string, bool) {
+	ascii := true
+	var sb strings.Builder
+	for _, c := range name {
+		if c < 0x20 || c > 0x7e || c == '"' || c == '\\' {
+			ascii = false
+			sb.WriteByte('_')
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String(), ascii
+}
+
+// encodeRFC5987 按 RFC 5987 attr-char 规则百分号编码
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0x0f])
+	}
+	return sb.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
+
+func writeFSError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// throttledReader 按固定速率读取，请求取消时停止等待
+type throttledReader struct {
+	io.ReadSeeker
+	ctx   context.Context
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	// 每次最多读取 100ms 的数据量，让输出更平滑
+	if chunk := max(t.rate/10, 1); int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.ReadSeeker.Read(p)
+	t.read += int64(n)
+	wait := time.Duration(float64(t.read)/float64(t.rate)*float64(time.Second)) - time.Since(t.start)
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,157 @@
+package xin_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fengjx/xin"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		inline   bool
+		want     string
+	}{
+		{"report.pdf", false, `attachment; filename="report.pdf"`},
+		{"report.pdf", true, `inline; filename="report.pdf"`},
+		{"月度报表 2024.xlsx", false, `attachment; filename="____ 2024.xlsx"; filename*=UTF-8''%E6%9C%88%E5%BA%A6%E6%8A%A5%E8%A1%A8%202024.xlsx`},
+		{`a"b/c.txt`, false, `attachment; filename="a_b_c.txt"; filename*=UTF-8''a%22b_c.txt`},
+	}
+	for _, tt := range tests {
+		if got := xin.ContentDisposition(tt.filename, tt.inline); got != tt.want {
+			t.Errorf("ContentDisposition(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	modtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	content := "0123456789abcdefghij"
+	serve := func(header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/download", nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		xin.Attachment(w, r, "数据.csv", strings.NewReader(content), modtime)
+		return w
+	}
+
+	w := serve(nil)
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "filename*=UTF-8''%E6%95%B0%E6%8D%AE.csv") {
+		t.Errorf("unexpected content disposition %q", cd)
+	}
+	if w.Header().Get("Accept-Ranges") != "bytes" {
+		t.Errorf("expected Accept-Ranges: bytes")
+	}
+
+	w = serve(http.Header{"Range": {"bytes=2-5"}})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "2345" {
+		t.Errorf("unexpected single range response %d %q", w.Code, w.Body.String())
+	}
+	if cr := w.Header().Get("Content-Range"); cr != "bytes 2-5/20" {
+		t.Errorf("unexpected content range %q", cr)
+	}
+
+	w = serve(http.Header{"Range": {"bytes=0-1,-2"}})
+	mediaType, params, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if w.Code != http.StatusPartialContent || mediaType != "multipart/byteranges" {
+		t.Fatalf("unexpected multi range response %d %q", w.Code, mediaType)
+	}
+	mr := multipart.NewReader(w.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(p)
+		parts = append(parts, string(b))
+	}
+	if strings.Join(parts, ",") != "01,ij" {
+		t.Errorf("unexpected parts %v", parts)
+	}
+
+	w = serve(http.Header{"If-Modified-Since": {modtime.Format(http.TimeFormat)}})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", w.Code)
+	}
+
+	// If-Range 不匹配时返回完整内容
+	w = serve(http.Header{"Range": {"bytes=0-1"}, "If-Range": {modtime.Add(-time.Hour).Format(http.TimeFormat)}})
+	if w.Code != http.StatusOK || w.Body.String() != content {
+		t.Errorf("expected full content when If-Range mismatched, got %d", w.Code)
+	}
+}
+
+func TestFileFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/readme.txt": {Data: []byte("hello xin"), ModTime: time.Unix(1700000000, 0)},
+	}
+	serve := func(name string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		xin.FileFromFS(w, r, fsys, name)
+		return w
+	}
+
+	w := serve("/docs/readme.txt", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "hello xin" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if w.Header().Get("Content-Disposition") != "" {
+		t.Errorf("FileFromFS should not set Content-Disposition")
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag")
+	}
+	if w = serve("docs/readme.txt", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", w.Code)
+	}
+	if w = serve("docs", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for directory, got %d", w.Code)
+	}
+	if w = serve("missing.txt", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestDownloadRateLimit(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	start := time.Now()
+	xin.ServeContent(w, r, strings.NewReader(strings.Repeat("x", 400)), time.Time{}, xin.DownloadOptions{
+		Filename:  "a.txt",
+		RateLimit: 2000,
+	})
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected throttled download, took %v", elapsed)
+	}
+	if w.Body.Len() != 400 {
+		t.Errorf("expected 400 bytes, got %d", w.Body.Len())
+	}
+}
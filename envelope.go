@@ -0,0 +1,239 @@
+package xin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fengjx/go-halo/json"
+)
+
+// BizError 业务错误，Fail 会使用它的业务码和提示信息
+type BizError struct {
+	// Code 业务码
+	Code int
+	// Msg 返回给调用方的提示信息
+	Msg string
+	// Status http 状态码，默认 200
+	Status int
+}
+
+// NewBizError 创建业务错误
+func NewBizError(code int, msg string) *BizError {
+	return &BizError{Code: code, Msg: msg}
+}
+
+func (e *BizError) Error() string {
+	return fmt.Sprintf("xin: biz error %d: %s", e.Code, e.Msg)
+}
+
+// WithStatus 返回设置了 http 状态码的副本
+func (e *BizError) WithStatus(status int) *BizError {
+	be := *e
+	be.Status = status
+	return &be
+}
+
+// EnvelopeOptions 响应封装配置，字段名为 "-" 时不输出该字段
+type EnvelopeOptions struct {
+	// CodeField 业务码字段名，默认 code
+	CodeField string
+	// MsgField 提示信息字段名，默认 msg
+	MsgField string
+	// DataField 数据字段名，默认 data
+	DataField string
+	// RequestIDField 请求 ID 字段名，默认 request_id，请求 ID 为空时不输出
+	RequestIDField string
+	// TraceIDField 链路 ID 字段名，默认 trace_id，链路 ID 为空时不输出
+	TraceIDField string
+
+	// SuccessCode 成功的业务码，默认 0
+	SuccessCode int
+	// SuccessMsg 成功的提示信息，默认 ok
+	SuccessMsg string
+	// InvalidParamCode 参数校验失败的业务码，默认 400，data 为翻译后的字段错误
+	InvalidParamCode int
+	// InvalidParamMsg 参数校验失败的提示信息，默认 invalid params
+	InvalidParamMsg string
+	// ErrorCode 未知错误的业务码，默认 500，http 状态码为 500
+	ErrorCode int
+	// ErrorMsg 未知错误的提示信息，默认 internal server error，不会返回原始错误信息
+	ErrorMsg string
+
+	// ListField 分页数据中列表的字段名，默认 list
+	ListField string
+	// TotalField 分页数据中总数的字段名，默认 total
+	TotalField string
+	// PageField 分页数据中页码的字段名，默认 page
+	PageField string
+	// SizeField 分页数据中每页数量的字段名，默认 size
+	SizeField string
+	// NextCursorField 游标分页中下一页游标的字段名，默认 next_cursor
+	NextCursorField string
+
+	// TraceID 从请求中获取链路 ID，默认读取 W3C traceparent 请求头
+	TraceID func(r *http.Request) string
+}
+
+var envelopeOptions = EnvelopeOptions{}.withDefaults()
+
+// SetEnvelopeOptions 设置响应封装配置，未设置的字段使用默认值
+func SetEnvelopeOptions(opts EnvelopeOptions) {
+	envelopeOptions = opts.withDefaults()
+}
+
+func (opts EnvelopeOptions) withDefaults() EnvelopeOptions {
+	setDefault := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	setDefault(&opts.CodeField, "code")
+	setDefault(&opts.MsgField, "msg")
+	setDefault(&opts.DataField, "data")
+	setDefault(&opts.RequestIDField, "request_id")
+	setDefault(&opts.TraceIDField, "trace_id")
+	setDefault(&opts.SuccessMsg, "ok")
+	setDefault(&opts.InvalidParamMsg, "invalid params")
+	setDefault(&opts.ErrorMsg, "internal server error")
+	setDefault(&opts.ListField, "list")
+	setDefault(&opts.TotalField, "total")
+	setDefault(&opts.PageField, "page")
+	setDefault(&opts.SizeField, "size")
+	setDefault(&opts.NextCursorField, "next_cursor")
+	if opts.InvalidParamCode == 0 {
+		opts.InvalidParamCode = http.StatusBadRequest
+	}
+	if opts.ErrorCode == 0 {
+		opts.ErrorCode = http.StatusInternalServerError
+	}
+	if opts.TraceID == nil {
+		opts.TraceID = traceParentID
+	}
+	return opts
+}
+
+// OK 返回成功响应
+//
+//	{"code":0,"msg":"ok","data":{...},"request_id":"..."}
+func OK(w http.ResponseWriter, r *http.Request, data any) error {
+	opts := envelopeOptions
+	return writeEnvelope(w, r, http.StatusOK, opts.SuccessCode, opts.SuccessMsg, data)
+}
+
+// OKPage 返回分页列表，page 和 size 来自 spec
+//
+//	{"code":0,"msg":"ok","data":{"list":[...],"total":100,"page":1,"size":20}}
+func OKPage(w http.ResponseWriter, r *http.Request, list any, total int64, spec *QuerySpec) error {
+	opts := envelopeOptions
+	data := envelopeObject{
+		{opts.ListField, list},
+		{opts.TotalField, total},
+	}
+	if spec != nil {
+		data = append(data, envelopeField{opts.PageField, spec.Page}, envelopeField{opts.SizeField, spec.Size})
+	}
+	return OK(w, r, data)
+}
+
+// OKCursor 返回游标分页列表，nextCursor 为空表示没有下一页
+//
+//	{"code":0,"msg":"ok","data":{"list":[...],"next_cursor":"..."}}
+func OKCursor(w http.ResponseWriter, r *http.Request, list any, nextCursor string) error {
+	opts := envelopeOptions
+	return OK(w, r, envelopeObject{
+		{opts.ListField, list},
+		{opts.NextCursorField, nextCursor},
+	})
+}
+
+// Fail 返回失败响应
+// err 为 *BizError 时使用它的业务码和提示信息；为参数校验错误时 data 为翻译后的字段错误；
+// 其他错误返回 ErrorCode 和 ErrorMsg，http 状态码为 500
+func Fail(w http.ResponseWriter, r *http.Request, err error) error {
+	opts := envelopeOptions
+	var be *BizError
+	if errors.As(err, &be) {
+		status := be.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		return writeEnvelope(w, r, status, be.Code, be.Msg, nil)
+	}
+	if fe := TranslateError(r, err); fe != nil {
+		return writeEnvelope(w, r, http.StatusBadRequest, opts.InvalidParamCode, opts.InvalidParamMsg, fe)
+	}
+	return writeEnvelope(w, r, http.StatusInternalServerError, opts.ErrorCode, opts.ErrorMsg, nil)
+}
+
+// FailCode 返回指定业务码和提示信息的失败响应，http 状态码为 200
+func FailCode(w http.ResponseWriter, r *http.Request, code int, msg string) error {
+	return writeEnvelope(w, r, http.StatusOK, code, msg, nil)
+}
+
+func writeEnvelope(w http.ResponseWriter, r *http.Request, status int, code int, msg string, data any) error {
+	opts := envelopeOptions
+	env := make(envelopeObject, 0, 5)
+	env = env.add(opts.CodeField, code)
+	env = env.add(opts.MsgField, msg)
+	if data != nil || code == opts.SuccessCode {
+		env = env.add(opts.DataField, data)
+	}
+	if id := RequestID(r.Context()); id != "" {
+		env = env.add(opts.RequestIDField, id)
+	}
+	if id := opts.TraceID(r); id != "" {
+		env = env.add(opts.TraceIDField, id)
+	}
+	return WriteJSON(w, status, env)
+}
+
+// traceParentID 从 W3C traceparent 请求头中获取 trace id
+func traceParentID(r *http.Request) string {
+	traceID, _ := parseTraceParent(r.Header.Get("traceparent"))
+	return traceID
+}
+
+type envelopeField struct {
+	key   string
+	value any
+}
+
+// envelopeObject 按字段顺序编码的 json 对象
+type envelopeObject []envelopeField
+
+func (o envelopeObject) add(key string, value any) envelopeObject {
+	if key == "-" {
+		return o
+	}
+	return append(o, envelopeField{key, value})
+}
+
+func (o envelopeObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	n := 0
+	for _, f := range o {
+		if f.key == "-" {
+			continue
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+		key, err := json.ToBytes(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.ToBytes(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
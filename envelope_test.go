@@ -0,0 +1,136 @@
+package xin_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fengjx/xin"
+	"github.com/fengjx/xin/middleware"
+)
+
+func TestEnvelope(t *testing.T) {
+	type createUser struct {
+		Name string `json:"name" binding:"required"`
+	}
+	bizErr := xin.NewBizError(10001, "user not found")
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request) error
+		header  http.Header
+		status  int
+		body    string
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.OK(w, r, xin.Map{"id": 1})
+			},
+			status: http.StatusOK,
+			body:   `{"code":0,"msg":"ok","data":{"id":1},"request_id":"req-1"}`,
+		},
+		{
+			name: "ok with trace id",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.OK(w, r, nil)
+			},
+			header: http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+			status: http.StatusOK,
+			body:   `{"code":0,"msg":"ok","data":null,"request_id":"req-1","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		},
+		{
+			name: "page",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.OKPage(w, r, []string{"a"}, 21, &xin.QuerySpec{Page: 2, Size: 20})
+			},
+			status: http.StatusOK,
+			body:   `{"code":0,"msg":"ok","data":{"list":["a"],"total":21,"page":2,"size":20},"request_id":"req-1"}`,
+		},
+		{
+			name: "cursor",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.OKCursor(w, r, []int{1}, "abc")
+			},
+			status: http.StatusOK,
+			body:   `{"code":0,"msg":"ok","data":{"list":[1],"next_cursor":"abc"},"request_id":"req-1"}`,
+		},
+		{
+			name: "biz error",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.Fail(w, r, fmt.Errorf("query: %w", bizErr))
+			},
+			status: http.StatusOK,
+			body:   `{"code":10001,"msg":"user not found","request_id":"req-1"}`,
+		},
+		{
+			name: "biz error with status",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.Fail(w, r, bizErr.WithStatus(http.StatusNotFound))
+			},
+			status: http.StatusNotFound,
+			body:   `{"code":10001,"msg":"user not found","request_id":"req-1"}`,
+		},
+		{
+			name: "validation error",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.Fail(w, r, xin.ValidateStruct(&createUser{}))
+			},
+			status: http.StatusBadRequest,
+			body:   `{"code":400,"msg":"invalid params","data":{"name":"name is a required field"},"request_id":"req-1"}`,
+		},
+		{
+			name: "unknown error",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return xin.Fail(w, r, errors.New("db password wrong"))
+			},
+			status: http.StatusInternalServerError,
+			body:   `{"code":500,"msg":"internal server error","request_id":"req-1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := tt.handler(w, r); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(middleware.RequestIDHeader, "req-1")
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if got := w.Body.String(); got != tt.body+"\n" {
+				t.Errorf("expected body %s, got %s", tt.body, got)
+			}
+		})
+	}
+}
+
+func TestEnvelopeOptions(t *testing.T) {
+	xin.SetEnvelopeOptions(xin.EnvelopeOptions{
+		CodeField:      "errno",
+		MsgField:       "errmsg",
+		DataField:      "result",
+		RequestIDField: "-",
+		SuccessMsg:     "success",
+	})
+	defer xin.SetEnvelopeOptions(xin.EnvelopeOptions{})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	if err := xin.OK(w, r, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"errno":0,"errmsg":"success","result":1}`
+	if got := w.Body.String(); got != want+"\n" {
+		t.Errorf("expected body %s, got %s", want, got)
+	}
+}
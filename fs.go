@@ -64,8 +64,24 @@ func (ofs onlyFilesFS) Open(name string) (fs.File, error) {
 
 // FileHandler 处理静态文件请求
 // 参考 http.StripPrefix
+// 存在与 Accept-Encoding 匹配的 .br、.zst、.gz 预压缩文件时优先返回预压缩文件
 func FileHandler(prefix string, fs fs.FS) http.Handler {
-	h := http.FileServerFS(fs)
+	return fileHandler(prefix, newStaticHandler(fs, StaticOptions{}))
+}
+
+// FileHandlerWith 与 FileHandler 相同，可以设置 ETag 和 Cache-Control，参考 StaticOptions
+func FileHandlerWith(prefix string, fs fs.FS, opts StaticOptions) http.Handler {
+	return fileHandler(prefix, newStaticHandler(fs, opts.withDefaults()))
+}
+
+func fileHandler(prefix string, sh *staticHandler) http.Handler {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upath := r.URL.Path
+		if !strings.HasPrefix(upath, "/") {
+			upath = "/" + upath
+		}
+		sh.serve(w, r, upath, sh.fileServer)
+	})
 	if prefix == "" {
 		return h
 	}
@@ -80,7 +96,9 @@ func FileHandler(prefix string, fs fs.FS) http.Handler {
 			}
 			if upath == "/" {
 				// 这里是为了避免 http fileHandler 重定向，导致访问错误
-				http.ServeFileFS(w, r, fs, path.Clean(upath))
+				sh.serve(w, r, upath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					http.ServeFileFS(w, r, sh.fsys, path.Clean(upath))
+				}))
 				return
 			}
 			r2 := new(http.Request)
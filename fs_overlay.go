@@ -0,0 +1,177 @@
+package xin
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+type overlayFS struct {
+	layers []fs.FS
+}
+
+// OverlayFS 将多个文件系统叠加为一个，按顺序从第一个存在该路径的文件系统中查找
+// 目录会合并所有文件系统中的同名目录，同名文件以靠前的文件系统为准
+// 可以用磁盘目录覆盖 embed.FS 中的部分文件，也可以再使用 OnlyFilesFS 包装
+//
+//	//go:embed theme
+//	var theme embed.FS
+//
+//	sub, _ := fs.Sub(theme, "theme")
+//	app.StaticFS("/theme/", xin.OverlayFS(os.DirFS("./custom"), sub))
+func OverlayFS(layers ...fs.FS) fs.FS {
+	return &overlayFS{layers: layers}
+}
+
+// Open 实现 fs.FS
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for i, layer := range o.layers {
+		f, err := layer.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			if shadowed(layer, name) {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if !stat.IsDir() {
+			return f, nil
+		}
+		return &overlayDir{File: f, fs: o, name: name, layer: i}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// Stat 实现 fs.StatFS
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o.layers {
+		stat, err := fs.Stat(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			if shadowed(layer, name) {
+				break
+			}
+			continue
+		}
+		return stat, err
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir 实现 fs.ReadDirFS，合并所有文件系统中的同名目录，按文件名排序
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	for i, layer := range o.layers {
+		stat, err := fs.Stat(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			if shadowed(layer, name) {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !stat.IsDir() {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+		}
+		return o.readDir(name, i)
+	}
+	return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+}
+
+// readDir 从第 from 个文件系统开始合并目录，遇到同名的文件时停止，它会遮住后面的目录
+func (o *overlayFS) readDir(name string, from int) ([]fs.DirEntry, error) {
+	seen := make(map[string]struct{})
+	var entries []fs.DirEntry
+	for _, layer := range o.layers[from:] {
+		stat, err := fs.Stat(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			if shadowed(layer, name) {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !stat.IsDir() {
+			break
+		}
+		layerEntries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range layerEntries {
+			if _, ok := seen[entry.Name()]; ok {
+				continue
+			}
+			seen[entry.Name()] = struct{}{}
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// shadowed name 的上级路径在 layer 中是文件时返回 true，后面的文件系统中的 name 会被遮住
+func shadowed(layer fs.FS, name string) bool {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if stat, err := fs.Stat(layer, dir); err == nil {
+			return !stat.IsDir()
+		}
+	}
+	return false
+}
+
+// overlayDir 合并后的目录，Stat 返回第一个文件系统中的目录信息
+type overlayDir struct {
+	fs.File
+	fs      *overlayFS
+	name    string
+	layer   int
+	entries []fs.DirEntry
+	offset  int
+	read    bool
+}
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.readDir(d.name, d.layer)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
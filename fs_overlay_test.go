@@ -0,0 +1,98 @@
+package xin_test
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fengjx/xin"
+)
+
+func TestOverlayFS(t *testing.T) {
+	custom := fstest.MapFS{
+		"logo.png":        {Data: []byte("custom logo")},
+		"css/theme.css":   {Data: []byte("custom theme")},
+		"docs/index.html": {Data: []byte("custom docs")},
+		"shadow":          {Data: []byte("file shadows dir")},
+	}
+	embedded := fstest.MapFS{
+		"index.html":      {Data: []byte("index")},
+		"logo.png":        {Data: []byte("logo")},
+		"css/theme.css":   {Data: []byte("theme")},
+		"css/base.css":    {Data: []byte("base")},
+		"docs/index.html": {Data: []byte("docs")},
+		"shadow/a.txt":    {Data: []byte("a")},
+	}
+	overlay := xin.OverlayFS(custom, embedded)
+
+	if err := fstest.TestFS(overlay, "index.html", "logo.png", "css/theme.css", "css/base.css", "shadow"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("read", func(t *testing.T) {
+		tests := map[string]string{
+			"index.html":    "index",
+			"logo.png":      "custom logo",
+			"css/theme.css": "custom theme",
+			"css/base.css":  "base",
+			"shadow":        "file shadows dir",
+		}
+		for name, want := range tests {
+			data, err := fs.ReadFile(overlay, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != want {
+				t.Errorf("%s: expected %q, got %q", name, want, data)
+			}
+		}
+		if _, err := fs.ReadFile(overlay, "shadow/a.txt"); err == nil {
+			t.Error("expected file in shadowed directory to be hidden")
+		}
+	})
+
+	t.Run("merge directory", func(t *testing.T) {
+		entries, err := fs.ReadDir(overlay, "css")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if got := strings.Join(names, ","); got != "base.css,theme.css" {
+			t.Errorf("unexpected entries %s", got)
+		}
+	})
+
+	t.Run("static", func(t *testing.T) {
+		mux := xin.NewMux()
+		mux.StaticFS("/", xin.OnlyFilesFS(overlay, false, ""))
+		tests := []struct {
+			path string
+			code int
+			body string
+		}{
+			{"/", http.StatusOK, "index"},
+			{"/logo.png", http.StatusOK, "custom logo"},
+			{"/css/base.css", http.StatusOK, "base"},
+			{"/docs", http.StatusOK, "custom docs"},
+			{"/css/", http.StatusNotFound, ""},
+			{"/missing.png", http.StatusNotFound, ""},
+		}
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.code {
+				t.Errorf("%s: expected status %d, got %d", tt.path, tt.code, w.Code)
+				continue
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("%s: expected body %q, got %q", tt.path, tt.body, w.Body.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,223 @@
+package xin
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fengjx/xin/render"
+)
+
+var (
+	// ErrTemplateNotFound 模板不存在
+	ErrTemplateNotFound = errors.New("xin: template not found")
+	// ErrHTMLTemplatesNotSet 没有调用 SetHTMLTemplates 设置模板
+	ErrHTMLTemplatesNotSet = errors.New("xin: html templates not set")
+)
+
+// HTMLOptions html 模板配置
+type HTMLOptions struct {
+	// Extensions 模板文件扩展名，默认 .html
+	Extensions []string
+	// LayoutDir 布局模板目录，默认 layouts
+	LayoutDir string
+	// PartialDir 公共片段模板目录，默认 partials
+	PartialDir string
+	// Funcs 自定义模板函数
+	Funcs template.FuncMap
+	// Delims 模板分隔符，默认 {{ 和 }}
+	Delims [2]string
+	// Data 返回每个请求的公共数据，如当前用户、csrf token 等
+	// 页面数据为 nil、Map 或 map[string]any 时与公共数据合并，key 相同时页面数据优先
+	Data func(r *http.Request) Map
+}
+
+// HTMLTemplates 从 fs.FS 加载的 html 模板
+//
+// 模板名是文件相对 fs 根目录的路径，如 "users/list.html"
+// LayoutDir 和 PartialDir 中的模板对所有页面可见，其他模板文件都是页面，每个页面是独立的模板集合，
+// 所以不同页面可以重复定义相同的 block，页面通过 template 引用布局：
+//
+//	{{/* layouts/base.html */}}
+//	<html><body>{{block "content" .}}{{end}}</body></html>
+//
+//	{{/* users/list.html */}}
+//	{{template "layouts/base.html" .}}
+//	{{define "content"}}{{range .users}}{{template "partials/user.html" .}}{{end}}{{end}}
+//
+// Debug 为 true 时每次渲染都会重新解析模板，修改模板文件后不需要重启服务
+type HTMLTemplates struct {
+	fsys  fs.FS
+	opts  HTMLOptions
+	mtx   sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewHTMLTemplates 从 fsys 加载 html 模板，可以使用 embed.FS 或 os.DirFS
+func NewHTMLTemplates(fsys fs.FS, opts HTMLOptions) (*HTMLTemplates, error) {
+	if len(opts.Extensions) == 0 {
+		opts.Extensions = []string{".html"}
+	}
+	if opts.LayoutDir == "" {
+		opts.LayoutDir = "layouts"
+	}
+	if opts.PartialDir == "" {
+		opts.PartialDir = "partials"
+	}
+	t := &HTMLTemplates{
+		fsys: fsys,
+		opts: opts,
+	}
+	if err := t.Load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Load 重新解析所有模板
+func (t *HTMLTemplates) Load() error {
+	shared, pageNames, err := t.scan()
+	if err != nil {
+		return err
+	}
+	pages := make(map[string]*template.Template, len(pageNames))
+	for _, name := range pageNames {
+		page, err := t.parsePage(shared, name)
+		if err != nil {
+			return err
+		}
+		pages[name] = page
+	}
+	t.mtx.Lock()
+	t.pages = pages
+	t.mtx.Unlock()
+	return nil
+}
+
+// Lookup 查找页面模板，不存在时返回 ErrTemplateNotFound
+func (t *HTMLTemplates) Lookup(name string) (*template.Template, error) {
+	if Debug {
+		shared, pageNames, err := t.scan()
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(pageNames, name) {
+			return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+		}
+		return t.parsePage(shared, name)
+	}
+	t.mtx.RLock()
+	page, ok := t.pages[name]
+	t.mtx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	return page, nil
+}
+
+// Render 渲染页面模板并写入响应
+// 模板执行失败时不会写入响应，返回的错误可以由调用方处理
+func (t *HTMLTemplates) Render(w http.ResponseWriter, r *http.Request, code int, name string, data any) error {
+	page, err := t.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if t.opts.Data != nil {
+		data = mergeHTMLData(t.opts.Data(r), data)
+	}
+	return Render(w, code, render.HTML{Template: page, Name: name, Data: data})
+}
+
+// scan 解析布局和公共片段模板，返回页面模板名
+func (t *HTMLTemplates) scan() (*template.Template, []string, error) {
+	shared := template.New("").Funcs(t.opts.Funcs).Delims(t.opts.Delims[0], t.opts.Delims[1])
+	var pageNames []string
+	err := fs.WalkDir(t.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !slices.Contains(t.opts.Extensions, path.Ext(name)) {
+			return nil
+		}
+		if !t.isShared(name) {
+			pageNames = append(pageNames, name)
+			return nil
+		}
+		return t.parseFile(shared, name)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return shared, pageNames, nil
+}
+
+func (t *HTMLTemplates) isShared(name string) bool {
+	return strings.HasPrefix(name, t.opts.LayoutDir+"/") || strings.HasPrefix(name, t.opts.PartialDir+"/")
+}
+
+func (t *HTMLTemplates) parsePage(shared *template.Template, name string) (*template.Template, error) {
+	page, err := shared.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if err = t.parseFile(page, name); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (t *HTMLTemplates) parseFile(set *template.Template, name string) error {
+	b, err := fs.ReadFile(t.fsys, name)
+	if err != nil {
+		return err
+	}
+	if _, err = set.New(name).Parse(string(b)); err != nil {
+		return fmt.Errorf("xin: parse template %s: %w", name, err)
+	}
+	return nil
+}
+
+// mergeHTMLData 合并公共数据和页面数据，页面数据不是 map 时原样返回
+func mergeHTMLData(common Map, data any) any {
+	if len(common) == 0 {
+		return data
+	}
+	var page map[string]any
+	switch v := data.(type) {
+	case nil:
+	case Map:
+		page = v
+	case map[string]any:
+		page = v
+	default:
+		return data
+	}
+	merged := make(Map, len(common)+len(page))
+	maps.Copy(merged, common)
+	maps.Copy(merged, page)
+	return merged
+}
+
+var htmlTemplates *HTMLTemplates
+
+// SetHTMLTemplates 设置 WriteHTML 使用的模板
+func SetHTMLTemplates(t *HTMLTemplates) {
+	htmlTemplates = t
+}
+
+// WriteHTML 使用 SetHTMLTemplates 设置的模板渲染页面
+//
+//	xin.WriteHTML(w, r, http.StatusOK, "users/list.html", xin.Map{"users": users})
+func WriteHTML(w http.ResponseWriter, r *http.Request, code int, name string, data any) error {
+	if htmlTemplates == nil {
+		return ErrHTMLTemplatesNotSet
+	}
+	return htmlTemplates.Render(w, r, code, name, data)
+}
@@ -0,0 +1,102 @@
+package xin_test
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fengjx/xin"
+)
+
+func newTemplateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}xin{{end}}</title><main>{{block "content" .}}{{end}}</main>`)},
+		"partials/user.html": {Data: []byte(`<li>{{upper .}}</li>`)},
+		"users/list.html": {Data: []byte(`{{template "layouts/base.html" .}}` +
+			`{{define "title"}}users - {{.site}}{{end}}` +
+			`{{define "content"}}<ul>{{range .users}}{{template "partials/user.html" .}}{{end}}</ul>{{end}}`)},
+		"index.html": {Data: []byte(`{{template "layouts/base.html" .}}{{define "content"}}<p>{{.msg}}</p>{{end}}`)},
+		"readme.txt": {Data: []byte(`ignored`)},
+	}
+}
+
+func TestHTMLTemplates(t *testing.T) {
+	fsys := newTemplateFS()
+	tpl, err := xin.NewHTMLTemplates(fsys, xin.HTMLOptions{
+		Funcs: template.FuncMap{"upper": strings.ToUpper},
+		Data: func(r *http.Request) xin.Map {
+			return xin.Map{"site": "admin", "msg": "default"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xin.SetHTMLTemplates(tpl)
+	defer xin.SetHTMLTemplates(nil)
+
+	render := func(name string, data any) (*httptest.ResponseRecorder, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		return w, xin.WriteHTML(w, r, http.StatusOK, name, data)
+	}
+
+	w, err := render("users/list.html", xin.Map{"users": []string{"foo", "<bar>"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<title>users - admin</title><main><ul><li>FOO</li><li>&lt;BAR&gt;</li></ul></main>`
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	// 页面之间的 block 互不影响，页面数据优先于公共数据
+	w, err = render("index.html", map[string]any{"msg": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `<title>xin</title><main><p>hello</p></main>`
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+
+	if _, err = render("readme.txt", nil); !errors.Is(err, xin.ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+
+	// 非调试模式使用已加载的模板
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`changed`)}
+	if w, _ = render("index.html", nil); w.Body.String() == "changed" {
+		t.Error("templates should not reload when debug is off")
+	}
+
+	xin.SetDebug(true)
+	defer xin.SetDebug(false)
+	if w, _ = render("index.html", nil); w.Body.String() != "changed" {
+		t.Errorf("templates should reload in debug mode, got %q", w.Body.String())
+	}
+}
+
+func TestHTMLTemplatesExecuteError(t *testing.T) {
+	fsys := fstest.MapFS{
+		"broken.html": {Data: []byte(`before{{.Missing.Field}}`)},
+	}
+	tpl, err := xin.NewHTMLTemplates(fsys, xin.HTMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err = tpl.Render(w, r, http.StatusOK, "broken.html", struct{ Missing *struct{ Field string } }{}); err == nil {
+		t.Fatal("expected execute error")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+}
@@ -1,11 +1,13 @@
 package xin
 
 import (
+	"bytes"
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
 
-	"github.com/fengjx/go-halo/json"
+	"github.com/fengjx/xin/render"
 )
 
 var (
@@ -38,25 +40,131 @@ func GetRealIP(r *http.Request) string {
 	return ip
 }
 
-// Write 写入响应内容
-func Write(w http.ResponseWriter, code int, contentType string, message any) error {
-	w.Header().Set("Content-Type", contentType)
+// RemoteIP 返回客户端ip，优先使用 GetRealIP，没有代理请求头时使用 RemoteAddr
+func RemoteIP(r *http.Request) string {
+	if ip := GetRealIP(r); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Render 使用 render.Render 写入响应，1xx、204、304 状态码不会写入 body
+// 响应内容先渲染到缓冲区，渲染失败时不会写入状态码和响应头，调用方可以继续写入错误响应
+// code 小于 0 时不写入状态码，由 render.Render 自行处理，如 render.Redirect
+func Render(w http.ResponseWriter, code int, r render.Render) error {
+	if code < 0 {
+		r.WriteContentType(w)
+		return r.Render(w)
+	}
+	if !render.BodyAllowedForStatus(code) {
+		r.WriteContentType(w)
+		w.WriteHeader(code)
+		return nil
+	}
+	bw := &bufferWriter{ResponseWriter: w, buf: render.GetBuffer()}
+	defer render.PutBuffer(bw.buf)
+	if err := r.Render(bw); err != nil {
+		return err
+	}
+	r.WriteContentType(w)
 	w.WriteHeader(code)
-	return json.NewEncoder(w).Encode(message)
+	_, err := w.Write(bw.buf.Bytes())
+	return err
+}
+
+// bufferWriter 将响应 body 写入缓冲区，用于渲染成功后再写入状态码
+type bufferWriter struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *bufferWriter) WriteHeader(int) {}
+
+// Write 写入响应内容，Content-Type 使用 contentType
+// message 为 string 或 []byte 时原样写入，其他类型编码为 json
+func Write(w http.ResponseWriter, code int, contentType string, message any) error {
+	var r render.Render
+	switch msg := message.(type) {
+	case string:
+		r = render.Data{Data: []byte(msg)}
+	case []byte:
+		r = render.Data{Data: msg}
+	default:
+		r = render.JSON{Data: message}
+	}
+	return Render(w, code, contentTypeRender{render: r, contentType: contentType})
+}
+
+// contentTypeRender 使用 contentType 覆盖 render.Render 的 Content-Type，渲染成功后才会设置
+type contentTypeRender struct {
+	render      render.Render
+	contentType string
+}
+
+func (r contentTypeRender) Render(w http.ResponseWriter) error {
+	return r.render.Render(w)
+}
+
+func (r contentTypeRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", r.contentType)
 }
 
-// WriteString 写入响应
+// WriteString 写入文本响应
 func WriteString(w http.ResponseWriter, code int, message any) error {
-	return Write(w, code, "text/plain", message)
+	return Render(w, code, render.String{Format: fmt.Sprint(message)})
+}
+
+// WriteStringf 写入格式化的文本响应
+func WriteStringf(w http.ResponseWriter, code int, format string, args ...any) error {
+	return Render(w, code, render.String{Format: format, Data: args})
 }
 
 // WriteJSON 写入JSON响应
 func WriteJSON(w http.ResponseWriter, code int, data any) error {
-	jsonData, err := json.ToJson(data)
-	if err != nil {
-		return err
-	}
-	return Write(w, code, "application/json", jsonData)
+	return Render(w, code, render.JSON{Data: data})
+}
+
+// WriteIndentedJSON 写入格式化缩进的JSON响应
+func WriteIndentedJSON(w http.ResponseWriter, code int, data any) error {
+	return Render(w, code, render.IndentedJSON{Data: data})
+}
+
+// WritePureJSON 写入不转义 html 字符的JSON响应
+func WritePureJSON(w http.ResponseWriter, code int, data any) error {
+	return Render(w, code, render.PureJSON{Data: data})
+}
+
+// WriteAsciiJSON 写入非 ASCII 字符转义后的JSON响应
+func WriteAsciiJSON(w http.ResponseWriter, code int, data any) error {
+	return Render(w, code, render.AsciiJSON{Data: data})
+}
+
+// WriteXML 写入XML响应
+func WriteXML(w http.ResponseWriter, code int, data any) error {
+	return Render(w, code, render.XML{Data: data})
+}
+
+// WriteYAML 写入YAML响应
+func WriteYAML(w http.ResponseWriter, code int, data any) error {
+	return Render(w, code, render.YAML{Data: data})
+}
+
+// WriteData 写入原始字节响应
+func WriteData(w http.ResponseWriter, code int, contentType string, data []byte) error {
+	return Render(w, code, render.Data{ContentType: contentType, Data: data})
+}
+
+// Redirect 重定向到 location，code 为 3xx 或 201
+func Redirect(w http.ResponseWriter, r *http.Request, code int, location string) error {
+	return Render(w, -1, render.Redirect{Code: code, Request: r, Location: location})
 }
 
 // WriteNoContent 只返回响应码，不返回内容
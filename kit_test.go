@@ -0,0 +1,105 @@
+package xin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name        string
+		write       func(w http.ResponseWriter) error
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name: "string",
+			write: func(w http.ResponseWriter) error {
+				return xin.WriteString(w, http.StatusOK, "Hello World!")
+			},
+			code:        http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "Hello World!",
+		},
+		{
+			name: "stringf",
+			write: func(w http.ResponseWriter) error {
+				return xin.WriteStringf(w, http.StatusBadRequest, "invalid %s", "name")
+			},
+			code:        http.StatusBadRequest,
+			contentType: "text/plain; charset=utf-8",
+			body:        "invalid name",
+		},
+		{
+			name: "json",
+			write: func(w http.ResponseWriter) error {
+				return xin.WriteJSON(w, http.StatusOK, xin.Map{"name": "foo"})
+			},
+			code:        http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        "{\"name\":\"foo\"}\n",
+		},
+		{
+			name: "write raw string",
+			write: func(w http.ResponseWriter) error {
+				return xin.Write(w, http.StatusOK, "text/csv", "a,b\n")
+			},
+			code:        http.StatusOK,
+			contentType: "text/csv",
+			body:        "a,b\n",
+		},
+		{
+			name: "no body for 204",
+			write: func(w http.ResponseWriter) error {
+				return xin.WriteJSON(w, http.StatusNoContent, xin.Map{"name": "foo"})
+			},
+			code:        http.StatusNoContent,
+			contentType: "application/json; charset=utf-8",
+			body:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.write(w); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, ct)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := xin.WriteJSON(w, http.StatusCreated, xin.Map{"ch": make(chan int)}); err == nil {
+		t.Fatal("expected encoding error")
+	}
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Fatalf("expected nothing written, got %q %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	if err := xin.Write(w, http.StatusCreated, "application/json", make(chan int)); err == nil {
+		t.Fatal("expected encoding error")
+	}
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Fatalf("expected nothing written by Write, got %q %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	http.Error(w, "internal error", http.StatusInternalServerError)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
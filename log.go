@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"sync/atomic"
 )
 
 // Logger 定义了日志接口
@@ -13,78 +16,133 @@ type Logger interface {
 	Debugf(format string, v ...any)
 	Info(v ...any)
 	Infof(format string, v ...any)
+	Warn(v ...any)
+	Warnf(format string, v ...any)
 	Error(v ...any)
 	Errorf(format string, v ...any)
+	// With 返回带有结构化字段的日志实例，args 与 slog.Logger.With 相同，如 "user_id", 1
+	With(args ...any) Logger
+	// SetDebug 设置是否启用调试日志
+	SetDebug(debug bool)
 }
 
 // stdLogger 是标准输出的日志实现
 type stdLogger struct {
 	infoLog  *log.Logger
 	debugLog *log.Logger
+	warnLog  *log.Logger
 	errorLog *log.Logger
-	debug    bool // 是否启用调试日志
+	debug    *atomic.Bool // 是否启用调试日志，With 返回的实例共享
+	fields   string       // With 设置的字段，格式为 " key=value"
 }
 
 // NewStdLogger 创建一个标准输出的日志实现
 func NewStdLogger() Logger {
-	return &stdLogger{
-		infoLog:  log.New(os.Stdout, "[INFO] ", log.LstdFlags),
-		debugLog: log.New(os.Stdout, "[DEBUG] ", log.LstdFlags),
-		errorLog: log.New(os.Stderr, "[ERROR] ", log.LstdFlags|log.Lshortfile),
-		debug:    false,
-	}
+	return NewCustomLogger(os.Stdout, os.Stdout, os.Stderr)
 }
 
-// NewCustomLogger 创建一个自定义输出的日志实现
+// NewCustomLogger 创建一个自定义输出的日志实现，warn 日志输出到 infoOut
 func NewCustomLogger(infoOut, debugOut, errorOut io.Writer) Logger {
 	return &stdLogger{
 		infoLog:  log.New(infoOut, "[INFO] ", log.LstdFlags),
 		debugLog: log.New(debugOut, "[DEBUG] ", log.LstdFlags),
+		warnLog:  log.New(infoOut, "[WARN] ", log.LstdFlags),
 		errorLog: log.New(errorOut, "[ERROR] ", log.LstdFlags|log.Lshortfile),
-		debug:    false,
+		debug:    new(atomic.Bool),
 	}
 }
 
 func (l *stdLogger) Info(v ...any) {
-	l.infoLog.Output(2, fmt.Sprint(v...))
+	l.infoLog.Output(2, fmt.Sprint(v...)+l.fields)
 }
 
 func (l *stdLogger) Infof(format string, v ...any) {
-	l.infoLog.Output(2, fmt.Sprintf(format, v...))
+	l.infoLog.Output(2, fmt.Sprintf(format, v...)+l.fields)
 }
 
 func (l *stdLogger) Debug(v ...any) {
-	if !l.debug {
+	if !l.debug.Load() {
 		return
 	}
-	l.debugLog.Output(2, fmt.Sprint(v...))
+	l.debugLog.Output(2, fmt.Sprint(v...)+l.fields)
 }
 
 func (l *stdLogger) Debugf(format string, v ...any) {
-	if !l.debug {
+	if !l.debug.Load() {
 		return
 	}
-	l.debugLog.Output(2, fmt.Sprintf(format, v...))
+	l.debugLog.Output(2, fmt.Sprintf(format, v...)+l.fields)
+}
+
+func (l *stdLogger) Warn(v ...any) {
+	l.warnLog.Output(2, fmt.Sprint(v...)+l.fields)
+}
+
+func (l *stdLogger) Warnf(format string, v ...any) {
+	l.warnLog.Output(2, fmt.Sprintf(format, v...)+l.fields)
 }
 
 func (l *stdLogger) Error(v ...any) {
-	l.errorLog.Output(2, fmt.Sprint(v...))
+	l.errorLog.Output(2, fmt.Sprint(v...)+l.fields)
 }
 
 func (l *stdLogger) Errorf(format string, v ...any) {
-	l.errorLog.Output(2, fmt.Sprintf(format, v...))
+	l.errorLog.Output(2, fmt.Sprintf(format, v...)+l.fields)
+}
+
+// With 返回带有字段的日志实例，字段以 key=value 的格式追加到日志末尾
+func (l *stdLogger) With(args ...any) Logger {
+	if len(args) == 0 {
+		return l
+	}
+	var sb strings.Builder
+	sb.WriteString(l.fields)
+	for _, attr := range argsToAttrs(args) {
+		sb.WriteByte(' ')
+		sb.WriteString(attr.String())
+	}
+	nl := *l
+	nl.fields = sb.String()
+	return &nl
 }
 
 // SetDebug 设置是否启用调试日志
 func (l *stdLogger) SetDebug(debug bool) {
-	l.debug = debug
+	l.debug.Store(debug)
+}
+
+// argsToAttrs 按 slog.Logger.With 的规则将参数转换为 slog.Attr
+func argsToAttrs(args []any) []slog.Attr {
+	var attrs []slog.Attr
+	for len(args) > 0 {
+		switch x := args[0].(type) {
+		case string:
+			if len(args) == 1 {
+				attrs = append(attrs, slog.String("!BADKEY", x))
+				args = nil
+				continue
+			}
+			attrs = append(attrs, slog.Any(x, args[1]))
+			args = args[2:]
+		case slog.Attr:
+			attrs = append(attrs, x)
+			args = args[1:]
+		default:
+			attrs = append(attrs, slog.Any("!BADKEY", x))
+			args = args[1:]
+		}
+	}
+	return attrs
 }
 
 // 默认的日志实例
 var defaultLogger = NewStdLogger()
 
-// SetLogger 设置全局默认的日志实例
+// SetLogger 设置全局默认的日志实例，框架内部的日志也会使用它
 func SetLogger(logger Logger) {
+	if Debug {
+		logger.SetDebug(true)
+	}
 	defaultLogger = logger
 }
 
@@ -109,6 +167,14 @@ func LogDebugf(format string, v ...any) {
 	defaultLogger.Debugf(format, v...)
 }
 
+func LogWarn(v ...any) {
+	defaultLogger.Warn(v...)
+}
+
+func LogWarnf(format string, v ...any) {
+	defaultLogger.Warnf(format, v...)
+}
+
 func LogError(v ...any) {
 	defaultLogger.Error(v...)
 }
@@ -0,0 +1,84 @@
+package xin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+type loggerKey struct{}
+
+// ctxLogger context 中的日志实例，route 在路由匹配后才能确定，调用 Log 时再添加
+type ctxLogger struct {
+	logger Logger
+	ctx    context.Context
+	routed atomic.Pointer[Logger]
+}
+
+func (l *ctxLogger) get() Logger {
+	if l.ctx == nil {
+		return l.logger
+	}
+	if logger := l.routed.Load(); logger != nil {
+		return *logger
+	}
+	pattern := RoutePattern(l.ctx)
+	if pattern == "" {
+		return l.logger
+	}
+	logger := l.logger.With("route", pattern)
+	l.routed.Store(&logger)
+	return logger
+}
+
+// WithLogger 将日志实例添加到 context 中
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, &ctxLogger{logger: logger})
+}
+
+// WithRequestLogger 返回 context 中带有请求日志实例的请求
+// 日志实例绑定了 request_id、method、path、remote_ip，存在 W3C traceparent 请求头时绑定 trace_id 和 span_id
+// 路由匹配后调用 Log 时还会绑定 route，如 "GET /users/{id}"
+// logger 为 nil 时使用全局默认的日志实例
+func WithRequestLogger(r *http.Request, logger Logger) *http.Request {
+	if logger == nil {
+		logger = defaultLogger
+	}
+	args := make([]any, 0, 12)
+	if id := RequestID(r.Context()); id != "" {
+		args = append(args, "request_id", id)
+	}
+	args = append(args, "method", r.Method, "path", r.URL.Path, "remote_ip", RemoteIP(r))
+	if traceID, spanID := parseTraceParent(r.Header.Get("traceparent")); traceID != "" {
+		args = append(args, "trace_id", traceID, "span_id", spanID)
+	}
+	ctx, rec := withRouteRecorder(r.Context())
+	l := &ctxLogger{logger: logger.With(args...), ctx: ctx}
+	r = r.WithContext(context.WithValue(ctx, loggerKey{}, l))
+	// 之后的中间件可能会复制请求，Mux 路由匹配时会重新记录；没有经过 Mux 时 http.ServeMux 会在这个请求上设置 Pattern
+	rec.r.Store(r)
+	return r
+}
+
+// Log 返回 context 中的日志实例，没有时返回全局默认的日志实例
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		xin.Log(r.Context()).Infof("create order %d", id)
+//	}
+func Log(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*ctxLogger); ok {
+		return l.get()
+	}
+	return defaultLogger
+}
+
+// parseTraceParent 解析 W3C traceparent 请求头，返回 trace id 和 parent span id
+// traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func parseTraceParent(traceparent string) (traceID, spanID string) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", ""
+	}
+	return parts[1], parts[2]
+}
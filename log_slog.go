@@ -0,0 +1,112 @@
+package xin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// slogLogger 基于 slog.Handler 的日志实现
+type slogLogger struct {
+	handler slog.Handler
+	level   *slog.LevelVar
+}
+
+// NewSlogLogger 创建基于 slog 的日志实现
+// level 用于在运行时修改日志级别，为 nil 时创建一个新的 slog.LevelVar，默认 Info
+// 可以与 handler 共用同一个 level，如 slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+func NewSlogLogger(logger *slog.Logger, level *slog.LevelVar) Logger {
+	if level == nil {
+		level = new(slog.LevelVar)
+	}
+	return &slogLogger{handler: logger.Handler(), level: level}
+}
+
+// LogLevel SetSlog 使用的日志级别，默认 Info，可以在运行时修改
+var LogLevel = new(slog.LevelVar)
+
+// SetSlog 使用 slog.Logger 作为全局默认的日志实例，日志级别由 LogLevel 控制
+//
+//	xin.SetSlog(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: xin.LogLevel})))
+//	// 运行时修改日志级别
+//	xin.LogLevel.Set(slog.LevelWarn)
+func SetSlog(logger *slog.Logger) {
+	SetLogger(NewSlogLogger(logger, LogLevel))
+}
+
+func (l *slogLogger) Debug(v ...any) {
+	l.log(slog.LevelDebug, fmt.Sprint(v...))
+}
+
+func (l *slogLogger) Debugf(format string, v ...any) {
+	l.logf(slog.LevelDebug, format, v...)
+}
+
+func (l *slogLogger) Info(v ...any) {
+	l.log(slog.LevelInfo, fmt.Sprint(v...))
+}
+
+func (l *slogLogger) Infof(format string, v ...any) {
+	l.logf(slog.LevelInfo, format, v...)
+}
+
+func (l *slogLogger) Warn(v ...any) {
+	l.log(slog.LevelWarn, fmt.Sprint(v...))
+}
+
+func (l *slogLogger) Warnf(format string, v ...any) {
+	l.logf(slog.LevelWarn, format, v...)
+}
+
+func (l *slogLogger) Error(v ...any) {
+	l.log(slog.LevelError, fmt.Sprint(v...))
+}
+
+func (l *slogLogger) Errorf(format string, v ...any) {
+	l.logf(slog.LevelError, format, v...)
+}
+
+// With 返回带有结构化字段的日志实例，与原实例共用日志级别
+func (l *slogLogger) With(args ...any) Logger {
+	if len(args) == 0 {
+		return l
+	}
+	return &slogLogger{handler: l.handler.WithAttrs(argsToAttrs(args)), level: l.level}
+}
+
+// SetDebug 设置日志级别为 Debug 或 Info
+func (l *slogLogger) SetDebug(debug bool) {
+	if debug {
+		l.level.Set(slog.LevelDebug)
+		return
+	}
+	l.level.Set(slog.LevelInfo)
+}
+
+func (l *slogLogger) enabled(level slog.Level) bool {
+	return level >= l.level.Level() && l.handler.Enabled(context.Background(), level)
+}
+
+func (l *slogLogger) logf(level slog.Level, format string, v ...any) {
+	if !l.enabled(level) {
+		return
+	}
+	l.write(level, fmt.Sprintf(format, v...))
+}
+
+func (l *slogLogger) log(level slog.Level, msg string) {
+	if !l.enabled(level) {
+		return
+	}
+	l.write(level, msg)
+}
+
+// write 调用栈为 write <- log/logf <- Debug/Info... <- 调用方
+func (l *slogLogger) write(level slog.Level, msg string) {
+	var pcs [1]uintptr
+	runtime.Callers(4, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	_ = l.handler.Handle(context.Background(), r)
+}
@@ -0,0 +1,121 @@
+package xin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	logger := xin.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level:     slog.LevelDebug,
+		AddSource: true,
+	})), level)
+
+	records := func() []map[string]any {
+		t.Helper()
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+		buf.Reset()
+		return records
+	}
+
+	logger.Debug("hidden")
+	logger.Info("hello ", "xin")
+	logger.Warnf("retry %d", 3)
+	logger.With("user_id", 1, slog.String("role", "admin")).Errorf("denied %s", "/admin")
+	got := records()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(got))
+	}
+	tests := []struct {
+		level string
+		msg   string
+	}{
+		{"INFO", "hello xin"},
+		{"WARN", "retry 3"},
+		{"ERROR", "denied /admin"},
+	}
+	for i, tt := range tests {
+		if got[i]["level"] != tt.level || got[i]["msg"] != tt.msg {
+			t.Errorf("expected %s %q, got %v %v", tt.level, tt.msg, got[i]["level"], got[i]["msg"])
+		}
+		source, _ := got[i]["source"].(map[string]any)
+		if file, _ := source["file"].(string); !strings.HasSuffix(file, "log_test.go") {
+			t.Errorf("expected source log_test.go, got %v", source["file"])
+		}
+	}
+	if got[2]["user_id"] != float64(1) || got[2]["role"] != "admin" {
+		t.Errorf("expected structured fields, got %v", got[2])
+	}
+
+	// 运行时修改日志级别
+	level.Set(slog.LevelError)
+	logger.Warn("hidden")
+	logger.Error("shown")
+	if got := records(); len(got) != 1 || got[0]["msg"] != "shown" {
+		t.Errorf("expected only error record, got %v", got)
+	}
+	logger.SetDebug(true)
+	logger.With("k", "v").Debug("debug")
+	if got := records(); len(got) != 1 || got[0]["msg"] != "debug" || got[0]["k"] != "v" {
+		t.Errorf("expected debug record, got %v", got)
+	}
+}
+
+func TestSetSlog(t *testing.T) {
+	t.Cleanup(func() {
+		xin.SetLogger(xin.NewStdLogger())
+		xin.LogLevel.Set(slog.LevelInfo)
+	})
+	var buf bytes.Buffer
+	xin.SetSlog(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: xin.LogLevel})))
+	xin.LogDebug("hidden")
+	xin.LogWarnf("disk %d%%", 90)
+	xin.LogLevel.Set(slog.LevelDebug)
+	xin.LogDebugf("shown %s", "debug")
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("unexpected debug output: %s", out)
+	}
+	for _, s := range []string{`level=WARN msg="disk 90%"`, `level=DEBUG msg="shown debug"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("expected output to contain %q, got %s", s, out)
+		}
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var info, debug, errOut bytes.Buffer
+	logger := xin.NewCustomLogger(&info, &debug, &errOut)
+	logger.Debug("hidden")
+	logger.With("request_id", "abc").Warn("slow")
+	logger.SetDebug(true)
+	logger.With("a", 1).With("b", "x").Debugf("n=%d", 2)
+	logger.Error("failed")
+
+	if !strings.Contains(info.String(), "[WARN] ") || !strings.Contains(info.String(), "slow request_id=abc") {
+		t.Errorf("unexpected info output %q", info.String())
+	}
+	if strings.Contains(debug.String(), "hidden") || !strings.Contains(debug.String(), "n=2 a=1 b=x") {
+		t.Errorf("unexpected debug output %q", debug.String())
+	}
+	if !strings.Contains(errOut.String(), "[ERROR] ") || !strings.Contains(errOut.String(), "failed") {
+		t.Errorf("unexpected error output %q", errOut.String())
+	}
+}
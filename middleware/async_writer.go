@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAsyncWriterClosed is returned by AsyncWriter.Write after Close.
+var ErrAsyncWriterClosed = errors.New("middleware: async writer closed")
+
+// AsyncWriterOptions configures an AsyncWriter.
+type AsyncWriterOptions struct {
+	// QueueSize is the number of pending writes, default 1024.
+	QueueSize int
+	// BufferSize is the size of the buffer in front of the underlying
+	// writer, default 64KB.
+	BufferSize int
+	// FlushInterval is the maximum time a write stays in the buffer,
+	// default 1s.
+	FlushInterval time.Duration
+	// Block makes Write wait when the queue is full. By default the write is
+	// dropped and counted by Dropped, so that a slow log sink never slows
+	// down requests.
+	Block bool
+}
+
+// AsyncWriter is an io.Writer that copies each write to a queue and writes
+// it to the underlying writer from a single goroutine through a buffer. It
+// is safe for concurrent use. Call Close to flush pending writes.
+type AsyncWriter struct {
+	opts    AsyncWriterOptions
+	queue   chan []byte
+	flush   chan chan struct{}
+	done    chan struct{}
+	mtx     sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+	err     atomic.Pointer[error]
+}
+
+// NewAsyncWriter returns an AsyncWriter writing to w.
+func NewAsyncWriter(w io.Writer, opts AsyncWriterOptions) *AsyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 64 << 10
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	aw := &AsyncWriter{
+		opts:  opts,
+		queue: make(chan []byte, opts.QueueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go aw.run(w)
+	return aw
+}
+
+// Write queues a copy of p. It returns the last error of the underlying
+// writer, if any, but p is still queued.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	aw.mtx.RLock()
+	defer aw.mtx.RUnlock()
+	if aw.closed {
+		return 0, ErrAsyncWriterClosed
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	if aw.opts.Block {
+		aw.queue <- b
+	} else {
+		select {
+		case aw.queue <- b:
+		default:
+			aw.dropped.Add(1)
+		}
+	}
+	if err := aw.err.Load(); err != nil {
+		return len(p), *err
+	}
+	return len(p), nil
+}
+
+// Flush writes all queued data to the underlying writer.
+func (aw *AsyncWriter) Flush() {
+	aw.mtx.RLock()
+	if aw.closed {
+		aw.mtx.RUnlock()
+		return
+	}
+	ch := make(chan struct{})
+	aw.flush <- ch
+	aw.mtx.RUnlock()
+	<-ch
+}
+
+// Dropped returns the number of writes dropped because the queue was full.
+func (aw *AsyncWriter) Dropped() int64 {
+	return aw.dropped.Load()
+}
+
+// Close flushes the queued data and stops the writer goroutine. It does not
+// close the underlying writer.
+func (aw *AsyncWriter) Close() error {
+	aw.mtx.Lock()
+	if !aw.closed {
+		aw.closed = true
+		close(aw.queue)
+	}
+	aw.mtx.Unlock()
+	<-aw.done
+	if err := aw.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (aw *AsyncWriter) run(w io.Writer) {
+	defer close(aw.done)
+	ticker := time.NewTicker(aw.opts.FlushInterval)
+	defer ticker.Stop()
+	bw := bufio.NewWriterSize(w, aw.opts.BufferSize)
+	// a failed bufio.Writer keeps returning the error, so the buffered data
+	// is discarded and the writer reset to recover from transient errors
+	fail := func(err error) {
+		aw.err.Store(&err)
+		bw.Reset(w)
+	}
+	write := func(b []byte) {
+		if _, err := bw.Write(b); err != nil {
+			fail(err)
+		}
+	}
+	flush := func() {
+		if err := bw.Flush(); err != nil {
+			fail(err)
+		}
+	}
+	// drain writes everything that is already queued
+	drain := func() {
+		for {
+			select {
+			case b, ok := <-aw.queue:
+				if !ok {
+					return
+				}
+				write(b)
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case b, ok := <-aw.queue:
+			if !ok {
+				flush()
+				return
+			}
+			write(b)
+		case ch := <-aw.flush:
+			drain()
+			flush()
+			close(ch)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
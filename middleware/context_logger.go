@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/fengjx/xin"
+)
+
+// ContextLogger is a middleware that stores a request scoped logger in the
+// request context, so that all log lines of a request can be correlated.
+// Handlers retrieve it with xin.Log(r.Context()).
+//
+// The logger is bound to request_id, method, path, remote_ip and, when a W3C
+// traceparent header is present, trace_id and span_id. The route pattern is
+// added once the request has been routed. Place it after the RequestID
+// middleware so the request ID is available.
+func ContextLogger(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, xin.WithRequestLogger(r, xin.GetLogger()))
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	xin.SetLogger(xin.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), nil))
+	t.Cleanup(func() {
+		xin.SetLogger(xin.NewStdLogger())
+	})
+
+	r := NewRouter()
+	r.Use(RequestID, ContextLogger)
+	r.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		xin.Log(r.Context()).Infof("get user %s", r.PathValue("id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid log output %q: %v", buf.String(), err)
+	}
+	expected := map[string]any{
+		"msg":        "get user 1",
+		"request_id": "req-1",
+		"method":     "GET",
+		"path":       "/users/1",
+		"route":      "GET /users/{id}",
+		"remote_ip":  "10.0.0.1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, record[k])
+		}
+	}
+}
+
+func TestContextLoggerRequestCloned(t *testing.T) {
+	var buf bytes.Buffer
+	xin.SetLogger(xin.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), nil))
+	t.Cleanup(func() {
+		xin.SetLogger(xin.NewStdLogger())
+	})
+
+	type tenantKey struct{}
+	r := NewRouter()
+	r.Use(ContextLogger, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, "t1")))
+		})
+	})
+	r.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		xin.Log(r.Context()).Infof("get user %s", r.PathValue("id"))
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid log output %q: %v", buf.String(), err)
+	}
+	if record["route"] != "GET /users/{id}" {
+		t.Errorf("expected route GET /users/{id}, got %v", record["route"])
+	}
+}
+
+func TestLogWithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if xin.Log(req.Context()) != xin.GetLogger() {
+		t.Error("expected default logger")
+	}
+}
@@ -23,11 +23,11 @@ package middleware
 // The resulting handler is a standard net/http handler.
 
 import (
-	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
+
+	"github.com/fengjx/xin"
 )
 
 // CorsOptions is a configuration container to setup the CORS middleware.
@@ -120,8 +120,11 @@ func NewCORS(options CorsOptions) *Cors {
 		maxAge:            options.MaxAge,
 		optionPassthrough: options.OptionsPassthrough,
 	}
-	if options.Debug && c.Log == nil {
-		c.Log = log.New(os.Stdout, "[cors] ", log.LstdFlags)
+	if options.Debug {
+		c.Log = options.Log
+		if c.Log == nil {
+			c.Log = xinLog{prefix: "[cors] "}
+		}
 	}
 
 	// Normalize options
@@ -327,6 +330,16 @@ func (c *Cors) handleActualRequest(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// xinLog writes debug output through xin's default logger, so it follows
+// the logger configured with xin.SetLogger or xin.SetSlog.
+type xinLog struct {
+	prefix string
+}
+
+func (l xinLog) Printf(format string, v ...interface{}) {
+	xin.GetLogger().Infof(l.prefix+format, v...)
+}
+
 // convenience method. checks if a logger is set.
 func (c *Cors) logf(format string, a ...interface{}) {
 	if c.Log != nil {
@@ -334,6 +347,13 @@ func (c *Cors) logf(format string, a ...interface{}) {
 	}
 }
 
+// OriginAllowed reports whether the origin is allowed by the configured
+// AllowedOrigins or AllowOriginFunc. It can be used by handlers that need
+// the same origin rules outside of the CORS flow, such as WebSocket upgrades.
+func (c *Cors) OriginAllowed(r *http.Request, origin string) bool {
+	return c.isOriginAllowed(r, origin)
+}
+
 // isOriginAllowed checks if a given origin is allowed to perform cross-domain requests
 // on the endpoint
 func (c *Cors) isOriginAllowed(r *http.Request, origin string) bool {
@@ -0,0 +1,381 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fengjx/xin"
+)
+
+// LogField is a field emitted by the JSON and logfmt access log formatters.
+type LogField string
+
+const (
+	FieldTime         LogField = "time"
+	FieldRequestID    LogField = "request_id"
+	FieldRemoteIP     LogField = "remote_ip"
+	FieldMethod       LogField = "method"
+	FieldHost         LogField = "host"
+	FieldPath         LogField = "path"
+	FieldRoute        LogField = "route"
+	FieldProto        LogField = "proto"
+	FieldStatus       LogField = "status"
+	FieldBytesIn      LogField = "bytes_in"
+	FieldBytesOut     LogField = "bytes_out"
+	FieldDuration     LogField = "duration_ms"
+	FieldUpstreamTime LogField = "upstream_ms"
+	FieldUserID       LogField = "user_id"
+	FieldUserAgent    LogField = "user_agent"
+	FieldReferer      LogField = "referer"
+)
+
+// DefaultLogFields are the fields emitted when AccessLogOptions.Fields is empty.
+var DefaultLogFields = []LogField{
+	FieldTime, FieldRequestID, FieldRemoteIP, FieldMethod, FieldPath, FieldRoute,
+	FieldStatus, FieldBytesIn, FieldBytesOut, FieldDuration, FieldUserAgent,
+}
+
+// AccessLogOptions configures the access log formatters.
+type AccessLogOptions struct {
+	// Fields selects and orders the fields of the JSON and logfmt formats.
+	// Apache formats have a fixed layout. Default is DefaultLogFields.
+	Fields []LogField
+
+	// UserID returns the user of a request, used by FieldUserID and the
+	// Apache %u field. It receives the request as it was routed, so values
+	// stored in the context by authentication middleware are visible.
+	// SetLogUserID takes precedence over it.
+	UserID func(r *http.Request) string
+}
+
+// NewJSONLogFormatter returns a LogFormatter writing one JSON object per
+// request to w. Wrap w with NewAsyncWriter to keep the writes off the
+// request path.
+//
+//	out := middleware.NewAsyncWriter(os.Stdout, middleware.AsyncWriterOptions{})
+//	defer out.Close()
+//	r.Use(middleware.RequestLogger(middleware.NewJSONLogFormatter(out, middleware.AccessLogOptions{})))
+func NewJSONLogFormatter(w io.Writer, opts AccessLogOptions) LogFormatter {
+	return newAccessLogFormatter(w, opts, writeJSONLog)
+}
+
+// NewLogfmtFormatter returns a LogFormatter writing logfmt key=value lines to w.
+func NewLogfmtFormatter(w io.Writer, opts AccessLogOptions) LogFormatter {
+	return newAccessLogFormatter(w, opts, writeLogfmt)
+}
+
+// NewCommonLogFormatter returns a LogFormatter writing the Apache Common Log
+// Format to w:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326
+func NewCommonLogFormatter(w io.Writer, opts AccessLogOptions) LogFormatter {
+	return newAccessLogFormatter(w, opts, writeCommonLog)
+}
+
+// NewCombinedLogFormatter returns a LogFormatter writing the Apache Combined
+// Log Format to w, which is the Common Log Format followed by the referer and
+// the user agent.
+func NewCombinedLogFormatter(w io.Writer, opts AccessLogOptions) LogFormatter {
+	return newAccessLogFormatter(w, opts, writeCombinedLog)
+}
+
+// AddUpstreamTime adds d to the upstream time recorded by the access log
+// formatters for the request, e.g. the time spent calling a backend service.
+func AddUpstreamTime(r *http.Request, d time.Duration) {
+	if entry, ok := GetLogEntry(r).(*accessLogEntry); ok {
+		entry.upstream.Add(int64(d))
+	}
+}
+
+// SetLogUserID sets the user recorded by the access log formatters for the request.
+func SetLogUserID(r *http.Request, userID string) {
+	if entry, ok := GetLogEntry(r).(*accessLogEntry); ok {
+		entry.userID.Store(&userID)
+	}
+}
+
+type accessLogFormatter struct {
+	mtx    sync.Mutex
+	w      io.Writer
+	opts   AccessLogOptions
+	encode func(buf *bytes.Buffer, e *accessLogEntry, rec *accessLogRecord)
+	bufs   sync.Pool
+}
+
+func newAccessLogFormatter(w io.Writer, opts AccessLogOptions, encode func(*bytes.Buffer, *accessLogEntry, *accessLogRecord)) *accessLogFormatter {
+	if len(opts.Fields) == 0 {
+		opts.Fields = DefaultLogFields
+	}
+	return &accessLogFormatter{
+		w:      w,
+		opts:   opts,
+		encode: encode,
+		bufs: sync.Pool{New: func() any {
+			return new(bytes.Buffer)
+		}},
+	}
+}
+
+// NewLogEntry creates a new LogEntry for the request.
+func (f *accessLogFormatter) NewLogEntry(r *http.Request) LogEntry {
+	entry := &accessLogEntry{
+		formatter: f,
+		start:     time.Now(),
+		request:   r,
+		reqID:     GetReqID(r.Context()),
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		entry.body = &countingReader{ReadCloser: r.Body}
+		r.Body = entry.body
+	}
+	return entry
+}
+
+type accessLogEntry struct {
+	formatter *accessLogFormatter
+	start     time.Time
+	request   *http.Request
+	reqID     string
+	body      *countingReader
+	upstream  atomic.Int64
+	userID    atomic.Pointer[string]
+	panicked  any
+}
+
+// accessLogRecord holds the values resolved when the request completes.
+type accessLogRecord struct {
+	status   int
+	bytes    int
+	elapsed  time.Duration
+	routed   *http.Request
+	userID   string
+	bytesIn  int64
+	upstream time.Duration
+}
+
+func (e *accessLogEntry) Write(status, size int, header http.Header, elapsed time.Duration, extra interface{}) {
+	f := e.formatter
+	rec := &accessLogRecord{
+		status:   status,
+		bytes:    size,
+		elapsed:  elapsed,
+		routed:   xin.RoutedRequest(e.request.Context()),
+		upstream: time.Duration(e.upstream.Load()),
+	}
+	if rec.routed == nil {
+		rec.routed = e.request
+	}
+	if id := e.userID.Load(); id != nil {
+		rec.userID = *id
+	} else if f.opts.UserID != nil {
+		rec.userID = f.opts.UserID(rec.routed)
+	}
+	if e.body != nil {
+		rec.bytesIn = e.body.n.Load()
+	}
+
+	buf := f.bufs.Get().(*bytes.Buffer)
+	buf.Reset()
+	f.encode(buf, e, rec)
+	buf.WriteByte('\n')
+	f.mtx.Lock()
+	_, _ = f.w.Write(buf.Bytes())
+	f.mtx.Unlock()
+	f.bufs.Put(buf)
+}
+
+func (e *accessLogEntry) Panic(v interface{}, stack []byte) {
+	e.panicked = v
+	xin.GetLogger().Errorf("panic: %v\n%s", v, stack)
+}
+
+// value returns the value of a field, either a string, an int64 or a float64.
+func (e *accessLogEntry) value(field LogField, rec *accessLogRecord) any {
+	r := e.request
+	switch field {
+	case FieldTime:
+		return e.start.Format(time.RFC3339Nano)
+	case FieldRequestID:
+		// RequestID may run after the logger, the routed request carries the ID
+		if id := GetReqID(rec.routed.Context()); id != "" {
+			return id
+		}
+		return e.reqID
+	case FieldRemoteIP:
+		return xin.RemoteIP(r)
+	case FieldMethod:
+		return r.Method
+	case FieldHost:
+		return r.Host
+	case FieldPath:
+		return r.RequestURI
+	case FieldRoute:
+		return rec.routed.Pattern
+	case FieldProto:
+		return r.Proto
+	case FieldStatus:
+		return int64(rec.status)
+	case FieldBytesIn:
+		return rec.bytesIn
+	case FieldBytesOut:
+		return int64(rec.bytes)
+	case FieldDuration:
+		return durationMillis(rec.elapsed)
+	case FieldUpstreamTime:
+		return durationMillis(rec.upstream)
+	case FieldUserID:
+		return rec.userID
+	case FieldUserAgent:
+		return r.UserAgent()
+	case FieldReferer:
+		return r.Referer()
+	}
+	return ""
+}
+
+func writeJSONLog(buf *bytes.Buffer, e *accessLogEntry, rec *accessLogRecord) {
+	buf.WriteByte('{')
+	for i, field := range e.formatter.opts.Fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSONString(buf, string(field))
+		buf.WriteByte(':')
+		switch v := e.value(field, rec).(type) {
+		case string:
+			writeJSONString(buf, v)
+		case int64:
+			buf.WriteString(strconv.FormatInt(v, 10))
+		case float64:
+			buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	if e.panicked != nil {
+		buf.WriteString(`,"panic":`)
+		writeJSONString(buf, fmt.Sprint(e.panicked))
+	}
+	buf.WriteByte('}')
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+func writeLogfmt(buf *bytes.Buffer, e *accessLogEntry, rec *accessLogRecord) {
+	for i, field := range e.formatter.opts.Fields {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(string(field))
+		buf.WriteByte('=')
+		switch v := e.value(field, rec).(type) {
+		case string:
+			writeLogfmtValue(buf, v)
+		case int64:
+			buf.WriteString(strconv.FormatInt(v, 10))
+		case float64:
+			buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	if e.panicked != nil {
+		buf.WriteString(" panic=")
+		writeLogfmtValue(buf, fmt.Sprint(e.panicked))
+	}
+}
+
+// writeLogfmtValue quotes values that are empty or contain spaces, quotes,
+// equal signs or control characters.
+func writeLogfmtValue(buf *bytes.Buffer, s string) {
+	if s != "" && !strings.ContainsFunc(s, func(c rune) bool {
+		return c <= ' ' || c == '=' || c == '"' || c == 0x7f
+	}) {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(strconv.Quote(s))
+}
+
+func writeCommonLog(buf *bytes.Buffer, e *accessLogEntry, rec *accessLogRecord) {
+	r := e.request
+	buf.WriteString(xin.RemoteIP(r))
+	buf.WriteString(" - ")
+	writeApacheValue(buf, rec.userID)
+	buf.WriteString(" [")
+	buf.WriteString(e.start.Format("02/Jan/2006:15:04:05 -0700"))
+	buf.WriteString(`] "`)
+	buf.WriteString(apacheEscape(r.Method + " " + r.RequestURI + " " + r.Proto))
+	buf.WriteString(`" `)
+	buf.WriteString(strconv.Itoa(rec.status))
+	buf.WriteByte(' ')
+	if rec.bytes == 0 {
+		buf.WriteByte('-')
+	} else {
+		buf.WriteString(strconv.Itoa(rec.bytes))
+	}
+}
+
+func writeCombinedLog(buf *bytes.Buffer, e *accessLogEntry, rec *accessLogRecord) {
+	writeCommonLog(buf, e, rec)
+	buf.WriteString(` "`)
+	writeApacheValue(buf, e.request.Referer())
+	buf.WriteString(`" "`)
+	writeApacheValue(buf, e.request.UserAgent())
+	buf.WriteByte('"')
+}
+
+func writeApacheValue(buf *bytes.Buffer, s string) {
+	if s == "" {
+		buf.WriteByte('-')
+		return
+	}
+	buf.WriteString(apacheEscape(s))
+}
+
+// apacheEscape escapes quotes, backslashes and non printable characters the
+// way Apache httpd does in its access log.
+func apacheEscape(s string) string {
+	if !strings.ContainsFunc(s, func(c rune) bool {
+		return c < ' ' || c == '"' || c == '\\' || c >= 0x7f
+	}) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < ' ' || c >= 0x7f:
+			fmt.Fprintf(&sb, "\\x%02x", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// countingReader counts the bytes read from the request body.
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
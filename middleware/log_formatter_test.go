@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type userKey struct{}
+
+func newAccessLogRouter(f LogFormatter) http.Handler {
+	r := NewRouter()
+	r.Use(RequestLogger(f), RequestID, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), userKey{}, "alice")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.POST("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		AddUpstreamTime(r, 15*time.Millisecond)
+		AddUpstreamTime(r, 5*time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	})
+	r.GET("/me", func(w http.ResponseWriter, r *http.Request) {
+		SetLogUserID(r, "bob")
+	})
+	return r
+}
+
+func newAccessLogRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/orders/1?debug=1", strings.NewReader(`{"n":1}`))
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("User-Agent", `curl/8.0 "test"`)
+	req.Header.Set("Referer", "https://example.com/")
+	return req
+}
+
+func TestJSONLogFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewJSONLogFormatter(&buf, AccessLogOptions{
+		Fields: []LogField{
+			FieldRequestID, FieldRemoteIP, FieldMethod, FieldPath, FieldRoute, FieldStatus,
+			FieldBytesIn, FieldBytesOut, FieldUpstreamTime, FieldUserID, FieldUserAgent, FieldDuration,
+		},
+		UserID: func(r *http.Request) string {
+			user, _ := r.Context().Value(userKey{}).(string)
+			return user
+		},
+	})
+	newAccessLogRouter(f).ServeHTTP(httptest.NewRecorder(), newAccessLogRequest())
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	expected := map[string]any{
+		"request_id":  "req-1",
+		"remote_ip":   "10.0.0.1",
+		"method":      "POST",
+		"path":        "/orders/1?debug=1",
+		"route":       "POST /orders/{id}",
+		"status":      float64(201),
+		"bytes_in":    float64(7),
+		"bytes_out":   float64(7),
+		"upstream_ms": float64(20),
+		"user_id":     "alice",
+		"user_agent":  `curl/8.0 "test"`,
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, record[k])
+		}
+	}
+	if _, ok := record["duration_ms"].(float64); !ok {
+		t.Errorf("expected duration_ms, got %v", record["duration_ms"])
+	}
+	if !strings.HasPrefix(buf.String(), `{"request_id":"req-1","remote_ip"`) {
+		t.Errorf("expected fields in order, got %s", buf.String())
+	}
+
+	buf.Reset()
+	newAccessLogRouter(f).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/me", nil))
+	if !strings.Contains(buf.String(), `"user_id":"bob"`) {
+		t.Errorf("expected user_id set by SetLogUserID, got %s", buf.String())
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewLogfmtFormatter(&buf, AccessLogOptions{
+		Fields: []LogField{FieldMethod, FieldRoute, FieldStatus, FieldUserAgent, FieldReferer, FieldUserID},
+	})
+	newAccessLogRouter(f).ServeHTTP(httptest.NewRecorder(), newAccessLogRequest())
+	expected := `method=POST route="POST /orders/{id}" status=201 user_agent="curl/8.0 \"test\"" referer=https://example.com/ user_id=""` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestApacheLogFormatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		newFunc func(io.Writer, AccessLogOptions) LogFormatter
+		pattern string
+	}{
+		{
+			"common", NewCommonLogFormatter,
+			`^10\.0\.0\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /orders/1\?debug=1 HTTP/1\.1" 201 7\n$`,
+		},
+		{
+			"combined", NewCombinedLogFormatter,
+			`^10\.0\.0\.1 - - \[[^\]]+\] "POST /orders/1\?debug=1 HTTP/1\.1" 201 7 "https://example\.com/" "curl/8\.0 \\"test\\""\n$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			newAccessLogRouter(tt.newFunc(&buf, AccessLogOptions{})).ServeHTTP(httptest.NewRecorder(), newAccessLogRequest())
+			if !regexp.MustCompile(tt.pattern).MatchString(buf.String()) {
+				t.Errorf("unexpected log line %q", buf.String())
+			}
+		})
+	}
+
+	t.Run("combined without referer and user agent", func(t *testing.T) {
+		var buf bytes.Buffer
+		req := newAccessLogRequest()
+		req.Header.Del("Referer")
+		req.Header.Del("User-Agent")
+		newAccessLogRouter(NewCombinedLogFormatter(&buf, AccessLogOptions{})).ServeHTTP(httptest.NewRecorder(), req)
+		if !strings.HasSuffix(buf.String(), ` 201 7 "-" "-"`+"\n") {
+			t.Errorf("expected \"-\" for empty referer and user agent, got %q", buf.String())
+		}
+	})
+}
+
+type blockingWriter struct {
+	mtx     sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if w.release != nil {
+		<-w.release
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Run("flush and close", func(t *testing.T) {
+		out := &blockingWriter{}
+		aw := NewAsyncWriter(out, AsyncWriterOptions{FlushInterval: time.Hour})
+		_, _ = aw.Write([]byte("a\n"))
+		_, _ = aw.Write([]byte("b\n"))
+		aw.Flush()
+		if got := out.String(); got != "a\nb\n" {
+			t.Fatalf("expected flushed lines, got %q", got)
+		}
+		_, _ = aw.Write([]byte("c\n"))
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != "a\nb\nc\n" {
+			t.Errorf("expected all lines after close, got %q", got)
+		}
+		if _, err := aw.Write([]byte("d\n")); err != ErrAsyncWriterClosed {
+			t.Errorf("expected ErrAsyncWriterClosed, got %v", err)
+		}
+	})
+
+	t.Run("drop when full", func(t *testing.T) {
+		out := &blockingWriter{release: make(chan struct{})}
+		aw := NewAsyncWriter(out, AsyncWriterOptions{QueueSize: 1, BufferSize: 1})
+		for range 10 {
+			_, _ = aw.Write([]byte("line\n"))
+		}
+		if aw.Dropped() == 0 {
+			t.Error("expected dropped writes")
+		}
+		close(out.release)
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
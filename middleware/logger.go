@@ -11,6 +11,8 @@ import (
 	"os"
 	"runtime"
 	"time"
+
+	"github.com/fengjx/xin"
 )
 
 var (
@@ -52,6 +54,8 @@ func Logger(next http.Handler) http.Handler {
 func RequestLogger(f LogFormatter) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			// the route pattern is only known after routing, see xin.RoutedRequest
+			r = xin.WithRouteRecorder(r)
 			entry := f.NewLogEntry(r)
 			ww := NewWrapResponseWriter(w, r.ProtoMajor)
 
@@ -60,7 +64,9 @@ func RequestLogger(f LogFormatter) func(next http.Handler) http.Handler {
 				entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), nil)
 			}()
 
-			next.ServeHTTP(ww, WithLogEntry(r, entry))
+			// record the request passed on: a plain http.ServeMux sets Pattern on the
+			// request it receives, xin.Mux records the routed request itself
+			next.ServeHTTP(ww, xin.WithRouteRecorder(WithLogEntry(r, entry)))
 		}
 		return http.HandlerFunc(fn)
 	}
@@ -168,7 +174,12 @@ func (l *defaultLogEntry) Write(status, bytes int, header http.Header, elapsed t
 }
 
 func (l *defaultLogEntry) Panic(v interface{}, stack []byte) {
-	PrintPrettyStack(v)
+	out, err := prettyStack{}.parse(stack, v)
+	if err != nil {
+		// print stdlib output as a fallback
+		out = stack
+	}
+	l.Logger.Print(string(out))
 }
 
 func init() {
@@ -12,12 +12,17 @@ import (
 	"os"
 	"runtime/debug"
 	"strings"
+
+	"github.com/fengjx/xin"
 )
 
 // Recoverer is a middleware that recovers from panics, logs the panic (and a
 // backtrace), and returns a HTTP 500 (Internal Server Error) status if
 // possible. Recoverer prints a request ID if one is provided.
 //
+// Without a LogEntry the panic is logged through xin.Log, the logger set with
+// xin.SetLogger or ContextLogger, with the stack as the "stack" field.
+//
 // Alternatively, look at https://github.com/go-chi/httplog middleware pkgs.
 func Recoverer(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +38,7 @@ func Recoverer(next http.Handler) http.Handler {
 				if logEntry != nil {
 					logEntry.Panic(rvr, debug.Stack())
 				} else {
-					PrintPrettyStack(rvr)
+					xin.Log(r.Context()).With("stack", string(debug.Stack())).Errorf("panic: %v", rvr)
 				}
 
 				if r.Header.Get("Connection") != "Upgrade" {
@@ -16,13 +16,13 @@ import (
 func panickingHandler(http.ResponseWriter, *http.Request) { panic("foo") }
 
 func TestRecoverer(t *testing.T) {
-	app := xin.NewMux()
-
-	oldRecovererErrorWriter := recovererErrorWriter
-	defer func() { recovererErrorWriter = oldRecovererErrorWriter }()
-	buf := &bytes.Buffer{}
-	recovererErrorWriter = buf
+	var info, debug, errOut bytes.Buffer
+	xin.SetLogger(xin.NewCustomLogger(&info, &debug, &errOut))
+	t.Cleanup(func() {
+		xin.SetLogger(xin.NewStdLogger())
+	})
 
+	app := xin.NewMux()
 	app.Use(Recoverer)
 	app.HandleFunc("GET /", panickingHandler)
 
@@ -32,6 +32,28 @@ func TestRecoverer(t *testing.T) {
 	res, _ := testRequest(t, ts, "GET", "/", nil)
 	assertEqual(t, res.StatusCode, http.StatusInternalServerError)
 
+	out := errOut.String()
+	if !strings.Contains(out, "[ERROR] ") || !strings.Contains(out, "panic: foo") {
+		t.Fatalf("expected panic logged through xin logger, got %q", out)
+	}
+	if !strings.Contains(out, "stack=") || !strings.Contains(out, "panickingHandler") {
+		t.Fatalf("expected stack field, got %q", out)
+	}
+}
+
+func TestPrintPrettyStack(t *testing.T) {
+	oldRecovererErrorWriter := recovererErrorWriter
+	defer func() { recovererErrorWriter = oldRecovererErrorWriter }()
+	buf := &bytes.Buffer{}
+	recovererErrorWriter = buf
+
+	func() {
+		defer func() {
+			PrintPrettyStack(recover())
+		}()
+		panickingHandler(nil, nil)
+	}()
+
 	lines := strings.Split(buf.String(), "\n")
 	for _, line := range lines {
 		if strings.HasPrefix(strings.TrimSpace(line), "->") {
@@ -12,6 +12,8 @@ import (
 	"os"
 	"strings"
 	"sync/atomic"
+
+	"github.com/fengjx/xin"
 )
 
 // Key to use when setting the request ID.
@@ -57,6 +59,9 @@ func init() {
 	}
 
 	prefix = fmt.Sprintf("%s/%s", hostname, b64[0:10])
+
+	// Let xin helpers such as the response envelope read the request ID.
+	xin.SetRequestIDFunc(GetReqID)
 }
 
 // RequestID is a middleware that injects a request ID into the context of each
@@ -117,6 +117,18 @@ func (b *basicWriter) Unwrap() http.ResponseWriter {
 	return b.ResponseWriter
 }
 
+// hijack takes over the connection. If no header was written, the handler
+// is switching protocols (e.g. WebSocket), so the status is recorded as 101.
+func (b *basicWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj := b.ResponseWriter.(http.Hijacker)
+	conn, brw, err := hj.Hijack()
+	if err == nil && !b.wroteHeader {
+		b.code = http.StatusSwitchingProtocols
+		b.wroteHeader = true
+	}
+	return conn, brw, err
+}
+
 // flushWriter ...
 type flushWriter struct {
 	basicWriter
@@ -136,8 +148,7 @@ type hijackWriter struct {
 }
 
 func (f *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	hj := f.basicWriter.ResponseWriter.(http.Hijacker)
-	return hj.Hijack()
+	return f.basicWriter.hijack()
 }
 
 var _ http.Hijacker = &hijackWriter{}
@@ -154,8 +165,7 @@ func (f *flushHijackWriter) Flush() {
 }
 
 func (f *flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	hj := f.basicWriter.ResponseWriter.(http.Hijacker)
-	return hj.Hijack()
+	return f.basicWriter.hijack()
 }
 
 var _ http.Flusher = &flushHijackWriter{}
@@ -176,8 +186,7 @@ func (f *httpFancyWriter) Flush() {
 }
 
 func (f *httpFancyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	hj := f.basicWriter.ResponseWriter.(http.Hijacker)
-	return hj.Hijack()
+	return f.basicWriter.hijack()
 }
 
 func (f *http2FancyWriter) Push(target string, opts *http.PushOptions) error {
@@ -1,9 +1,11 @@
 package xin
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/fengjx/go-halo/errs"
@@ -41,3 +43,21 @@ func TestRecoverer(t *testing.T) {
 		t.Error("Panic was not recovered")
 	}
 }
+
+func TestDefaultRecoverHandleLogger(t *testing.T) {
+	var info, debug, errOut bytes.Buffer
+	SetLogger(NewCustomLogger(&info, &debug, &errOut))
+	t.Cleanup(func() {
+		SetLogger(NewStdLogger())
+	})
+
+	x := New()
+	handler := recoverer(x.recoverHandle)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !strings.Contains(errOut.String(), "[ERROR] ") || !strings.Contains(errOut.String(), "panic: boom") {
+		t.Errorf("expected panic to be logged, got %q", errOut.String())
+	}
+}
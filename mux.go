@@ -49,7 +49,11 @@ func (mux *Mux) Group(prefix string) *Mux {
 }
 
 func (mux *Mux) then(h http.Handler) {
-	mux.handler = HandlerChain(h, mux.middlewares...)
+	routed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recordRoute(r)
+		h.ServeHTTP(w, r)
+	})
+	mux.handler = HandlerChain(routed, mux.middlewares...)
 }
 
 func (mux *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -127,14 +131,31 @@ func (mux *Mux) Static(pattern string, root string) *Mux {
 // StaticFS 注册静态文件服务，自定义文件系统
 // fs 可以使用 luchen.Dir() 创建
 func (mux *Mux) StaticFS(pattern string, fs fs.FS) *Mux {
-	prefix := pattern
-	// 处理 [METHOD /path] 格式
+	_, prefix := splitPattern(pattern)
+	mux.ServeMux.Handle(pattern, FileHandler(prefix, fs))
+	return mux
+}
+
+// StaticFSWith 注册静态文件服务，可以设置 ETag 和 Cache-Control，参考 StaticOptions
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	sub, _ := fs.Sub(dist, "dist")
+//	mux.StaticFSWith("/assets/", sub, xin.StaticOptions{HashETag: true})
+func (mux *Mux) StaticFSWith(pattern string, fs fs.FS, opts StaticOptions) *Mux {
+	_, prefix := splitPattern(pattern)
+	mux.ServeMux.Handle(pattern, FileHandlerWith(prefix, fs, opts))
+	return mux
+}
+
+// splitPattern 处理 [METHOD /path] 格式的路由，返回请求方法和路径前缀
+func splitPattern(pattern string) (method, prefix string) {
 	arr := strings.Fields(pattern)
 	if len(arr) > 1 {
-		prefix = arr[1]
+		return arr[0], arr[1]
 	}
-	mux.ServeMux.Handle(pattern, FileHandler(prefix, fs))
-	return mux
+	return "", pattern
 }
 
 // HandlerChain 使用中间件包装 handler
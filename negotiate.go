@@ -0,0 +1,224 @@
+package xin
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fengjx/go-halo/json"
+
+	"github.com/fengjx/xin/render"
+)
+
+// ErrNotAcceptable 没有与 Accept 匹配的渲染器
+var ErrNotAcceptable = errors.New("xin: not acceptable")
+
+// RendererFunc 根据响应数据创建 render.Render
+type RendererFunc func(data any) render.Render
+
+type rendererEntry struct {
+	mimeType string
+	fn       RendererFunc
+}
+
+var (
+	rendererMtx sync.RWMutex
+	// renderers 按注册顺序保存，Accept 为空或 */* 时使用第一个
+	renderers []rendererEntry
+)
+
+func init() {
+	RegisterRenderer(render.MIMEJSON, func(data any) render.Render {
+		return render.JSON{Data: data}
+	})
+	RegisterRenderer(render.MIMEXML, func(data any) render.Render {
+		return render.XML{Data: data}
+	})
+	RegisterRenderer("text/xml", func(data any) render.Render {
+		return render.XML{Data: data}
+	})
+	RegisterRenderer(render.MIMEYAML, func(data any) render.Render {
+		return render.YAML{Data: data}
+	})
+	RegisterRenderer("application/x-yaml", func(data any) render.Render {
+		return render.YAML{Data: data}
+	})
+	RegisterRenderer(render.MIMEMsgPack, func(data any) render.Render {
+		return render.MsgPack{Data: data}
+	})
+	RegisterRenderer("application/x-msgpack", func(data any) render.Render {
+		return render.MsgPack{Data: data}
+	})
+	RegisterRenderer(render.MIMEProtoBuf, func(data any) render.Render {
+		return render.ProtoBuf{Data: data}
+	})
+	RegisterRenderer(render.MIMECSV, func(data any) render.Render {
+		return render.CSV{Data: data}
+	})
+	RegisterRenderer(render.MIMEHTML, func(data any) render.Render {
+		return render.HTML{Template: negotiateHTMLTemplate, Data: data}
+	})
+}
+
+// negotiateHTMLTemplate 默认的 html 页面，将数据格式化为 json 显示
+var negotiateHTMLTemplate = template.Must(template.New("negotiate").Funcs(template.FuncMap{
+	"json": func(data any) (string, error) {
+		var sb strings.Builder
+		enc := json.NewEncoder(&sb)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(data)
+		return sb.String(), err
+	},
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"></head><body><pre>{{json .}}</pre></body></html>
+`))
+
+// RegisterRenderer 注册内容协商使用的渲染器，mimeType 已存在时替换原有渲染器
+//
+//	xin.RegisterRenderer("text/html", func(data any) render.Render {
+//		return render.HTML{Template: tmpl, Name: "data.html", Data: data}
+//	})
+func RegisterRenderer(mimeType string, fn RendererFunc) {
+	rendererMtx.Lock()
+	defer rendererMtx.Unlock()
+	mimeType = strings.ToLower(mimeType)
+	for i, entry := range renderers {
+		if entry.mimeType == mimeType {
+			renderers[i].fn = fn
+			return
+		}
+	}
+	renderers = append(renderers, rendererEntry{mimeType: mimeType, fn: fn})
+}
+
+// Negotiate 根据请求头 Accept 选择渲染器写入响应
+// 没有匹配的渲染器时返回 406 和 ErrNotAcceptable，响应头会添加 Vary: Accept
+func Negotiate(w http.ResponseWriter, r *http.Request, code int, data any) error {
+	return NegotiateOffers(w, r, code, data)
+}
+
+// NegotiateOffers 与 Negotiate 相同，只在 offers 指定的类型中选择
+// offers 为空时使用所有已注册的渲染器
+func NegotiateOffers(w http.ResponseWriter, r *http.Request, code int, data any, offers ...string) error {
+	addVary(w.Header(), "Accept")
+	entry, ok := negotiateRenderer(r.Header.Get("Accept"), offers)
+	if !ok {
+		w.WriteHeader(http.StatusNotAcceptable)
+		return ErrNotAcceptable
+	}
+	return Render(w, code, entry.fn(data))
+}
+
+// NegotiateFormat 返回与 Accept 最匹配的类型，没有匹配时返回空字符串
+func NegotiateFormat(r *http.Request, offers ...string) string {
+	entry, ok := negotiateRenderer(r.Header.Get("Accept"), offers)
+	if !ok {
+		return ""
+	}
+	return entry.mimeType
+}
+
+func negotiateRenderer(accept string, offers []string) (rendererEntry, bool) {
+	rendererMtx.RLock()
+	defer rendererMtx.RUnlock()
+	candidates := renderers
+	if len(offers) > 0 {
+		candidates = make([]rendererEntry, 0, len(offers))
+		for _, offer := range offers {
+			offer = strings.ToLower(offer)
+			for _, entry := range renderers {
+				if entry.mimeType == offer {
+					candidates = append(candidates, entry)
+					break
+				}
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return rendererEntry{}, false
+	}
+	mimeTypes := make([]string, len(candidates))
+	for i, entry := range candidates {
+		mimeTypes[i] = entry.mimeType
+	}
+	i := selectMediaType(accept, mimeTypes)
+	if i < 0 {
+		return rendererEntry{}, false
+	}
+	return candidates[i], true
+}
+
+// selectMediaType 返回 offers 中与 accept 最匹配的类型下标，没有匹配时返回 -1
+// accept 为空时返回第一个类型
+// 每个类型使用最具体的匹配项的 q 值，q=0 表示明确排除，如 "*/*, application/json;q=0"
+func selectMediaType(accept string, offers []string) int {
+	if len(offers) == 0 {
+		return -1
+	}
+	specs := parseAccept(accept)
+	if len(specs) == 0 {
+		return 0
+	}
+	// q 值相同时，具体的类型优先于通配
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].q != specs[j].q {
+			return specs[i].q > specs[j].q
+		}
+		return mediaSpecificity(specs[i].value) > mediaSpecificity(specs[j].value)
+	})
+	best, bestRank := -1, 0
+	for i, offer := range offers {
+		rank := -1
+		for j, spec := range specs {
+			if matchMediaRange(spec.value, offer) && (rank < 0 || mediaSpecificity(spec.value) > mediaSpecificity(specs[rank].value)) {
+				rank = j
+			}
+		}
+		if rank < 0 || specs[rank].q <= 0 {
+			continue
+		}
+		// specs 按 q 值排序，rank 越小越优先
+		if best < 0 || rank < bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+func mediaSpecificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchMediaRange 判断 mimeType 是否匹配 Accept 中的 mediaRange，如 "text/*"
+func matchMediaRange(mediaRange, mimeType string) bool {
+	mediaRange = strings.ToLower(mediaRange)
+	if mediaRange == "*/*" || mediaRange == mimeType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return strings.HasPrefix(mimeType, prefix+"/")
+	}
+	return false
+}
+
+// addVary 添加 Vary 响应头，已存在时不会重复添加
+func addVary(header http.Header, value string) {
+	for _, v := range header.Values("Vary") {
+		for _, item := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
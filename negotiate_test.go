@@ -0,0 +1,118 @@
+package xin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+type negotiateUser struct {
+	Name string `json:"name" xml:"name" csv:"user_name"`
+	Age  int    `json:"age" xml:"age"`
+}
+
+func TestNegotiate(t *testing.T) {
+	users := []negotiateUser{{Name: "foo", Age: 18}}
+	tests := []struct {
+		name        string
+		accept      string
+		offers      []string
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "empty accept",
+			accept:      "",
+			code:        http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `[{"name":"foo","age":18}]`,
+		},
+		{
+			name:        "browser prefers html",
+			accept:      "text/html;q=0.9,application/xml;q=0.8,*/*;q=0.7",
+			code:        http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			body:        "<pre>[\n  {\n    &#34;name&#34;: &#34;foo&#34;,",
+		},
+		{
+			name:        "html excluded",
+			accept:      "text/html;q=0,application/xml;q=0.8,*/*;q=0.7",
+			code:        http.StatusOK,
+			contentType: "application/xml; charset=utf-8",
+			body:        "<negotiateUser>",
+		},
+		{
+			name:        "csv export",
+			accept:      "text/csv",
+			code:        http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			body:        "user_name,age\nfoo,18\n",
+		},
+		{
+			name:        "specific type wins over wildcard",
+			accept:      "*/*, application/yaml",
+			code:        http.StatusOK,
+			contentType: "application/yaml; charset=utf-8",
+			body:        "- name: foo",
+		},
+		{
+			name:        "limited offers",
+			accept:      "application/xml, text/*;q=0.5",
+			offers:      []string{"application/json", "text/csv"},
+			code:        http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			body:        "user_name,age",
+		},
+		{
+			name:   "not acceptable",
+			accept: "image/png",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			name:   "json excluded",
+			accept: "application/json;q=0",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			name:   "excluded type overrides wildcard",
+			accept: "*/*, application/json;q=0",
+			offers: []string{"application/json"},
+			code:   http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			err := xin.NegotiateOffers(w, r, http.StatusOK, users, tt.offers...)
+			if tt.code == http.StatusNotAcceptable {
+				if !errors.Is(err, xin.ErrNotAcceptable) {
+					t.Errorf("expected ErrNotAcceptable, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("expected Vary: Accept, got %q", w.Header().Get("Vary"))
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("expected body contains %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package xin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MIMEMergePatchJSON RFC 7396 JSON Merge Patch
+	MIMEMergePatchJSON = "application/merge-patch+json"
+	// MIMEJSONPatch RFC 6902 JSON Patch
+	MIMEJSONPatch = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch patch 文档格式错误或无法应用
+	ErrInvalidPatch = errors.New("xin: invalid patch")
+	// ErrPatchTestFailed JSON Patch 中的 test 操作不满足
+	ErrPatchTestFailed = errors.New("xin: patch test failed")
+)
+
+// PatchOperation RFC 6902 JSON Patch 中的一个操作
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ShouldBindPatch 读取 PATCH 请求 body 并应用到 obj，返回发生变化的字段
+// Content-Type 为 application/json-patch+json 时按 RFC 6902 处理，否则按 RFC 7396 处理
+// 变化的字段使用 JSON Pointer 表示，如 "/name"、"/address/city"
+// 应用后的结果会使用 binding 标签校验，校验失败时 obj 不会被修改
+func ShouldBindPatch(r *http.Request, obj any) ([]string, error) {
+	body := &bodyReader{Reader: r.Body}
+	if bindOptions.MaxBodyBytes > 0 {
+		body.Reader = http.MaxBytesReader(nil, r.Body, bindOptions.MaxBodyBytes)
+	}
+	patch, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == MIMEJSONPatch {
+		return JSONPatch(obj, patch)
+	}
+	return MergePatch(obj, patch)
+}
+
+// MergePatch 将 RFC 7396 JSON Merge Patch 应用到 obj，返回发生变化的字段
+// patch 中值为 null 的字段会被重置为零值，未出现的字段保持不变
+func MergePatch(obj any, patch []byte) ([]string, error) {
+	var p any
+	if err := decodeJSON(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return applyPatch(obj, func(doc any) (any, error) {
+		return mergePatch(doc, p), nil
+	})
+}
+
+// JSONPatch 将 RFC 6902 JSON Patch 应用到 obj，返回发生变化的字段
+// 任意一个操作失败时 obj 不会被修改
+func JSONPatch(obj any, patch []byte) ([]string, error) {
+	var ops []PatchOperation
+	if err := decodeJSON(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return applyPatch(obj, func(doc any) (any, error) {
+		var err error
+		for i, op := range ops {
+			if doc, err = applyOperation(doc, op); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+		return doc, nil
+	})
+}
+
+// applyPatch 将 obj 转换为通用的 json 文档后执行 fn，再把结果写回 obj
+func applyPatch(obj any, fn func(doc any) (any, error)) ([]string, error) {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil, fmt.Errorf("%w: obj must be a non-nil pointer", ErrInvalidPatch)
+	}
+	origin, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var before, doc any
+	if err = decodeJSON(origin, &before); err != nil {
+		return nil, err
+	}
+	// 单独解析一份，patch 操作可以直接修改
+	if err = decodeJSON(origin, &doc); err != nil {
+		return nil, err
+	}
+	after, err := fn(doc)
+	if err != nil {
+		return nil, err
+	}
+	result, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// 在副本上写入结果并校验，成功后再替换 obj
+	cp := reflect.New(rv.Elem().Type())
+	cp.Elem().Set(rv.Elem())
+	resetJSONFields(cp.Elem())
+	if err = json.Unmarshal(result, cp.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if indirectType(cp.Elem().Type()).Kind() == reflect.Struct {
+		if err = structValidator.ValidateStruct(cp.Interface()); err != nil {
+			return nil, err
+		}
+	}
+	rv.Elem().Set(cp.Elem())
+
+	// 与写回后的结果比较，忽略没有实际改变 obj 的操作
+	current, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err = decodeJSON(current, &after); err != nil {
+		return nil, err
+	}
+	var changed []string
+	diffJSON("", before, after, &changed)
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// resetJSONFields 将参与 json 序列化的字段重置为零值，其他字段保持不变
+func resetJSONFields(rv reflect.Value) {
+	if rv.Kind() != reflect.Struct {
+		rv.Set(reflect.Zero(rv.Type()))
+		return
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && tagName(sf, "json") == "" {
+			resetJSONFields(rv.Field(i))
+			continue
+		}
+		if fv := rv.Field(i); fv.CanSet() {
+			fv.Set(reflect.Zero(sf.Type))
+		}
+	}
+}
+
+// mergePatch RFC 7396 合并算法
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+func applyOperation(doc any, op PatchOperation) (any, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %s %s missing value", ErrInvalidPatch, op.Op, op.Path)
+		}
+		var value any
+		if err := decodeJSON(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return pointerAdd(doc, op.Path, value)
+		case "replace":
+			doc, err := pointerRemove(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, op.Path, value)
+		default:
+			current, err := pointerGet(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return pointerRemove(doc, op.Path)
+	case "move", "copy":
+		value, err := pointerGet(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move %s into its child %s", ErrInvalidPatch, op.From, op.Path)
+			}
+			if doc, err = pointerRemove(doc, op.From); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopyJSON(value)
+		}
+		return pointerAdd(doc, op.Path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer 解析 RFC 6901 JSON Pointer
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, size int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return size, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > size || (!allowEnd && idx == size) || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return idx, nil
+}
+
+func pointerGet(doc any, path string) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, token := range tokens {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+			}
+			cur = v
+		case []any:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+		}
+	}
+	return cur, nil
+}
+
+// pointerAdd 在 path 处添加值，返回修改后的文档
+func pointerAdd(doc any, path string, value any) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+		}
+	})
+}
+
+// pointerRemove 删除 path 处的值，返回修改后的文档
+func pointerRemove(doc any, path string) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return updateParent(doc, tokens, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[token]; !ok {
+				return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+			}
+			delete(node, token)
+			return node, nil
+		case []any:
+			idx, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:idx], node[idx+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, path)
+		}
+	})
+}
+
+// updateParent 找到 tokens 对应的父节点并执行 fn，数组修改后需要写回上一级
+func updateParent(doc any, tokens []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+	token := tokens[0]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, "/"+strings.Join(tokens, "/"))
+		}
+		child, err := updateParent(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		idx, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := updateParent(node[idx], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = child
+		return node, nil
+	default:
+		return nil, fmt.Errorf("%w: path %q not found", ErrInvalidPatch, "/"+strings.Join(tokens, "/"))
+	}
+}
+
+func deepCopyJSON(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(node))
+		for k, child := range node {
+			m[k] = deepCopyJSON(child)
+		}
+		return m
+	case []any:
+		arr := make([]any, len(node))
+		for i, child := range node {
+			arr[i] = deepCopyJSON(child)
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// jsonEqual 比较两个 json 值，数字按数值比较
+func jsonEqual(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		if aerr == nil && berr == nil {
+			return af == bf
+		}
+		return an == bn
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diffJSON 比较 patch 前后的文档，记录发生变化的 JSON Pointer
+// 对象会逐个字段比较，数组和其他值作为整体比较
+func diffJSON(path string, before, after any, changed *[]string) {
+	bm, bok := before.(map[string]any)
+	am, aok := after.(map[string]any)
+	if !bok || !aok {
+		if !jsonEqual(before, after) {
+			if path == "" {
+				path = "/"
+			}
+			*changed = append(*changed, path)
+		}
+		return
+	}
+	for k, bv := range bm {
+		sub := path + "/" + escapePointer(k)
+		av, ok := am[k]
+		if !ok {
+			*changed = append(*changed, sub)
+			continue
+		}
+		diffJSON(sub, bv, av, changed)
+	}
+	for k := range am {
+		if _, ok := bm[k]; !ok {
+			*changed = append(*changed, path+"/"+escapePointer(k))
+		}
+	}
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
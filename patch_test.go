@@ -0,0 +1,108 @@
+package xin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+type patchAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type patchUser struct {
+	ID      int64        `json:"-"`
+	Name    string       `json:"name" binding:"required"`
+	Age     int          `json:"age"`
+	Tags    []string     `json:"tags"`
+	Address patchAddress `json:"address"`
+}
+
+func newPatchUser() *patchUser {
+	return &patchUser{
+		ID:      1,
+		Name:    "foo",
+		Age:     18,
+		Tags:    []string{"a", "b"},
+		Address: patchAddress{City: "sz", Zip: "518000"},
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	user := newPatchUser()
+	changed, err := xin.MergePatch(user, []byte(`{"age":null,"address":{"city":"gz"},"name":"foo"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.ID != 1 || user.Age != 0 || user.Address.City != "gz" || user.Address.Zip != "518000" {
+		t.Errorf("unexpected result: %+v", user)
+	}
+	if expected := []string{"/address/city", "/age"}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected changed %v, got %v", expected, changed)
+	}
+
+	user = newPatchUser()
+	if _, err = xin.MergePatch(user, []byte(`{"name":null}`)); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if user.Name != "foo" {
+		t.Errorf("obj should not be modified when validation fails: %+v", user)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	user := newPatchUser()
+	patch := `[
+		{"op":"test","path":"/name","value":"foo"},
+		{"op":"replace","path":"/name","value":"bar"},
+		{"op":"add","path":"/tags/-","value":"c"},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/address/city","path":"/address/zip"}
+	]`
+	changed, err := xin.JSONPatch(user, []byte(patch))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Name != "bar" || !reflect.DeepEqual(user.Tags, []string{"b", "c"}) || user.Address.Zip != "sz" {
+		t.Errorf("unexpected result: %+v", user)
+	}
+	if expected := []string{"/address/zip", "/name", "/tags"}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected changed %v, got %v", expected, changed)
+	}
+
+	user = newPatchUser()
+	_, err = xin.JSONPatch(user, []byte(`[{"op":"replace","path":"/age","value":20},{"op":"test","path":"/name","value":"x"}]`))
+	if !errors.Is(err, xin.ErrPatchTestFailed) {
+		t.Errorf("expected ErrPatchTestFailed, got %v", err)
+	}
+	if user.Age != 18 {
+		t.Errorf("obj should not be modified when patch fails: %+v", user)
+	}
+
+	_, err = xin.JSONPatch(user, []byte(`[{"op":"remove","path":"/missing"}]`))
+	if !errors.Is(err, xin.ErrInvalidPatch) {
+		t.Errorf("expected ErrInvalidPatch, got %v", err)
+	}
+}
+
+func TestShouldBindPatch(t *testing.T) {
+	user := newPatchUser()
+	r := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`[{"op":"replace","path":"/age","value":30}]`))
+	r.Header.Set("Content-Type", xin.MIMEJSONPatch)
+	changed, err := xin.ShouldBindPatch(r, user)
+	if err != nil || user.Age != 30 || len(changed) != 1 {
+		t.Errorf("unexpected result: %+v %v %v", user, changed, err)
+	}
+
+	r = httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"age":40}`))
+	r.Header.Set("Content-Type", xin.MIMEMergePatchJSON)
+	if _, err = xin.ShouldBindPatch(r, user); err != nil || user.Age != 40 {
+		t.Errorf("unexpected result: %+v %v", user, err)
+	}
+}
@@ -0,0 +1,247 @@
+package xin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize 默认每页数量
+	DefaultPageSize = 20
+	// DefaultMaxPageSize 默认每页最大数量
+	DefaultMaxPageSize = 100
+	// DefaultMaxPage 默认最大页码，避免过大的页码导致偏移量溢出或深分页查询
+	DefaultMaxPage = 10000
+)
+
+// ErrInvalidQuerySpec 分页、排序或过滤参数不合法
+var ErrInvalidQuerySpec = errors.New("xin: invalid query spec")
+
+// FilterOp 过滤操作符
+type FilterOp string
+
+const (
+	OpEq   FilterOp = "eq"   // 等于
+	OpNe   FilterOp = "ne"   // 不等于
+	OpGt   FilterOp = "gt"   // 大于
+	OpGte  FilterOp = "gte"  // 大于等于
+	OpLt   FilterOp = "lt"   // 小于
+	OpLte  FilterOp = "lte"  // 小于等于
+	OpIn   FilterOp = "in"   // 包含，多个值用 "," 分隔
+	OpLike FilterOp = "like" // 模糊匹配
+)
+
+var filterOps = []FilterOp{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpLike}
+
+// SortField 排序字段
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Filter 过滤条件
+type Filter struct {
+	// Name 参数中的字段名
+	Name string
+	// Field 数据库列名，没有配置 Columns 映射时与 Name 相同
+	Field string
+	Op    FilterOp
+	// Values 过滤值，OpIn 可能有多个值，其他操作符只有一个值
+	Values []string
+}
+
+// Value 返回第一个过滤值
+func (f Filter) Value() string {
+	if len(f.Values) == 0 {
+		return ""
+	}
+	return f.Values[0]
+}
+
+// QuerySpec 列表查询的分页、排序和过滤参数
+// 所有字段名都经过白名单校验，可以安全地用于拼接 SQL 的列名，过滤值仍需使用占位符传参
+type QuerySpec struct {
+	Page    int
+	Size    int
+	Cursor  string
+	Sorts   []SortField
+	Filters []Filter
+}
+
+// Offset 根据页码和每页数量计算偏移量
+func (q *QuerySpec) Offset() int {
+	return (q.Page - 1) * q.Size
+}
+
+// Filter 查找指定字段和操作符的过滤条件，field 可以是参数中的字段名或数据库列名
+func (q *QuerySpec) Filter(field string, op FilterOp) (Filter, bool) {
+	for _, f := range q.Filters {
+		if (f.Name == field || f.Field == field) && f.Op == op {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
+
+// QuerySpecOptions 列表查询参数的解析配置
+type QuerySpecOptions struct {
+	// DefaultSize 默认每页数量，默认 DefaultPageSize
+	DefaultSize int
+	// MaxSize 每页最大数量，超出时使用最大值，默认 DefaultMaxPageSize
+	MaxSize int
+	// MaxPage 最大页码，超出时返回 ErrInvalidQuerySpec，默认 DefaultMaxPage
+	MaxPage int
+	// SortFields 允许排序的字段
+	SortFields []string
+	// DefaultSorts 没有 sort 参数时使用的排序，字段需要在 SortFields 中，同样会使用 Columns 映射
+	DefaultSorts []SortField
+	// FilterFields 允许过滤的字段和操作符，操作符为空表示允许所有操作符
+	FilterFields map[string][]FilterOp
+	// Columns 参数字段名到数据库列名的映射，未配置的字段使用参数字段名
+	Columns map[string]string
+}
+
+// ParseQuerySpec 从 url 参数解析分页、排序和过滤条件
+//
+//	?page=2&size=10&sort=-created_at,name&filter[status]=active&filter[age][gte]=18
+//
+// sort 中 "-" 前缀表示降序，filter 不指定操作符时为 eq
+// 字段不在白名单中或参数格式错误时返回 ErrInvalidQuerySpec
+func ParseQuerySpec(r *http.Request, opts QuerySpecOptions) (*QuerySpec, error) {
+	return ParseQuerySpecValues(r.URL.Query(), opts)
+}
+
+// ParseQuerySpecValues 从 url.Values 解析分页、排序和过滤条件，参考 ParseQuerySpec
+func ParseQuerySpecValues(values url.Values, opts QuerySpecOptions) (*QuerySpec, error) {
+	if opts.DefaultSize <= 0 {
+		opts.DefaultSize = DefaultPageSize
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxPageSize
+	}
+	if opts.MaxPage <= 0 {
+		opts.MaxPage = DefaultMaxPage
+	}
+	spec := &QuerySpec{
+		Page:   1,
+		Size:   opts.DefaultSize,
+		Cursor: values.Get("cursor"),
+	}
+	var err error
+	if v := values.Get("page"); v != "" {
+		if spec.Page, err = strconv.Atoi(v); err != nil || spec.Page < 1 || spec.Page > opts.MaxPage {
+			return nil, fmt.Errorf("%w: page %q", ErrInvalidQuerySpec, v)
+		}
+	}
+	if v := values.Get("size"); v != "" {
+		if spec.Size, err = strconv.Atoi(v); err != nil || spec.Size < 1 {
+			return nil, fmt.Errorf("%w: size %q", ErrInvalidQuerySpec, v)
+		}
+	}
+	spec.Size = min(spec.Size, opts.MaxSize)
+
+	if spec.Sorts, err = parseSorts(values["sort"], opts); err != nil {
+		return nil, err
+	}
+	if len(spec.Sorts) == 0 {
+		for _, sort := range opts.DefaultSorts {
+			if !slices.Contains(opts.SortFields, sort.Field) {
+				return nil, fmt.Errorf("%w: default sort field %q not allowed", ErrInvalidQuerySpec, sort.Field)
+			}
+			sort.Field = opts.column(sort.Field)
+			spec.Sorts = append(spec.Sorts, sort)
+		}
+	}
+	if spec.Filters, err = parseFilters(values, opts); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func parseSorts(params []string, opts QuerySpecOptions) ([]SortField, error) {
+	var sorts []SortField
+	for _, param := range params {
+		for _, item := range strings.Split(param, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			sort := SortField{Field: item}
+			if name, ok := strings.CutPrefix(item, "-"); ok {
+				sort = SortField{Field: name, Desc: true}
+			} else if name, ok = strings.CutPrefix(item, "+"); ok {
+				sort.Field = name
+			}
+			if !slices.Contains(opts.SortFields, sort.Field) {
+				return nil, fmt.Errorf("%w: sort field %q not allowed", ErrInvalidQuerySpec, sort.Field)
+			}
+			sort.Field = opts.column(sort.Field)
+			sorts = append(sorts, sort)
+		}
+	}
+	return sorts, nil
+}
+
+func parseFilters(values url.Values, opts QuerySpecOptions) ([]Filter, error) {
+	var filters []Filter
+	for key, vals := range values {
+		rest, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+		field, rest, ok := strings.Cut(rest, "]")
+		if !ok || field == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidQuerySpec, key)
+		}
+		op := OpEq
+		if rest != "" {
+			name, ok := strings.CutPrefix(rest, "[")
+			if !ok || !strings.HasSuffix(name, "]") {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidQuerySpec, key)
+			}
+			op = FilterOp(strings.TrimSuffix(name, "]"))
+			if !slices.Contains(filterOps, op) {
+				return nil, fmt.Errorf("%w: filter operator %q not supported", ErrInvalidQuerySpec, op)
+			}
+		}
+		allowedOps, ok := opts.FilterFields[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: filter field %q not allowed", ErrInvalidQuerySpec, field)
+		}
+		if len(allowedOps) > 0 && !slices.Contains(allowedOps, op) {
+			return nil, fmt.Errorf("%w: filter operator %q not allowed on %q", ErrInvalidQuerySpec, op, field)
+		}
+		if op != OpIn && len(vals) > 1 {
+			return nil, fmt.Errorf("%w: filter %q has multiple values", ErrInvalidQuerySpec, key)
+		}
+		filter := Filter{Name: field, Field: opts.column(field), Op: op}
+		for _, v := range vals {
+			if op == OpIn {
+				filter.Values = append(filter.Values, strings.Split(v, ",")...)
+			} else {
+				filter.Values = append(filter.Values, v)
+			}
+		}
+		filters = append(filters, filter)
+	}
+	// url.Values 是 map，排序后保证结果稳定
+	slices.SortFunc(filters, func(a, b Filter) int {
+		if c := strings.Compare(a.Field, b.Field); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Op), string(b.Op))
+	})
+	return filters, nil
+}
+
+func (opts QuerySpecOptions) column(field string) string {
+	if col, ok := opts.Columns[field]; ok {
+		return col
+	}
+	return field
+}
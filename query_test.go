@@ -0,0 +1,87 @@
+package xin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+func TestParseQuerySpec(t *testing.T) {
+	opts := xin.QuerySpecOptions{
+		MaxSize:    50,
+		SortFields: []string{"created_at", "name"},
+		FilterFields: map[string][]xin.FilterOp{
+			"status":     nil,
+			"age":        {xin.OpGte, xin.OpLte},
+			"created_at": {xin.OpGte},
+		},
+		Columns: map[string]string{"created_at": "ctime"},
+	}
+
+	r := httptest.NewRequest(http.MethodGet,
+		"/?page=2&size=80&sort=-created_at,name&filter[status][in]=active,locked&filter[age][gte]=18&filter[created_at][gte]=2024-01-01", nil)
+	spec, err := xin.ParseQuerySpec(r, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Page != 2 || spec.Size != 50 || spec.Offset() != 50 {
+		t.Errorf("unexpected page: %+v", spec)
+	}
+	if len(spec.Sorts) != 2 || spec.Sorts[0] != (xin.SortField{Field: "ctime", Desc: true}) || spec.Sorts[1].Desc {
+		t.Errorf("unexpected sorts: %+v", spec.Sorts)
+	}
+	if f, ok := spec.Filter("age", xin.OpGte); !ok || f.Value() != "18" {
+		t.Errorf("unexpected age filter: %+v", spec.Filters)
+	}
+	if f, ok := spec.Filter("status", xin.OpIn); !ok || len(f.Values) != 2 {
+		t.Errorf("unexpected status filter: %+v", spec.Filters)
+	}
+	// 可以使用参数中的字段名或映射后的列名查找
+	for _, name := range []string{"created_at", "ctime"} {
+		if f, ok := spec.Filter(name, xin.OpGte); !ok || f.Name != "created_at" || f.Field != "ctime" {
+			t.Errorf("unexpected %s filter: %+v", name, spec.Filters)
+		}
+	}
+
+	invalid := []string{
+		"/?page=0",
+		"/?filter[age][gte]=18&filter[age][gte]=20",
+		"/?page=10001",
+		"/?page=9223372036854775807&size=100",
+		"/?size=abc",
+		"/?sort=password",
+		"/?filter[password]=1",
+		"/?filter[age][ne]=1",
+		"/?filter[age][drop]=1",
+		"/?filter[age=1",
+	}
+	for _, target := range invalid {
+		r = httptest.NewRequest(http.MethodGet, target, nil)
+		if _, err = xin.ParseQuerySpec(r, opts); !errors.Is(err, xin.ErrInvalidQuerySpec) {
+			t.Errorf("%s: expected ErrInvalidQuerySpec, got %v", target, err)
+		}
+	}
+}
+
+func TestParseQuerySpecDefaultSorts(t *testing.T) {
+	opts := xin.QuerySpecOptions{
+		SortFields:   []string{"created_at"},
+		DefaultSorts: []xin.SortField{{Field: "created_at", Desc: true}},
+		Columns:      map[string]string{"created_at": "ctime"},
+	}
+	spec, err := xin.ParseQuerySpec(httptest.NewRequest(http.MethodGet, "/", nil), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spec.Sorts) != 1 || spec.Sorts[0] != (xin.SortField{Field: "ctime", Desc: true}) {
+		t.Errorf("unexpected sorts: %+v", spec.Sorts)
+	}
+
+	opts.DefaultSorts = []xin.SortField{{Field: "password"}}
+	if _, err = xin.ParseQuerySpec(httptest.NewRequest(http.MethodGet, "/", nil), opts); !errors.Is(err, xin.ErrInvalidQuerySpec) {
+		t.Errorf("expected ErrInvalidQuerySpec for default sort not in SortFields, got %v", err)
+	}
+}
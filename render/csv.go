@@ -0,0 +1,100 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var csvContentType = MIMECSV + charsetSuffix
+
+// CSV csv 渲染
+// Data 支持 [][]string，以及结构体或结构体指针的切片
+// 结构体切片会输出表头，列名使用 csv 标签，没有 csv 标签时使用 json 标签
+type CSV struct {
+	Data any
+}
+
+func (r CSV) Render(w http.ResponseWriter) error {
+	records, err := csvRecords(r.Data)
+	if err != nil {
+		return err
+	}
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	cw := csv.NewWriter(buf)
+	if err = cw.WriteAll(records); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (r CSV) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, csvContentType)
+}
+
+func csvRecords(data any) ([][]string, error) {
+	if records, ok := data.([][]string); ok {
+		return records, nil
+	}
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("render: csv unsupported type %T", data)
+	}
+	et := rv.Type().Elem()
+	for et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("render: csv unsupported type %T", data)
+	}
+
+	var (
+		header  []string
+		indexes []int
+	)
+	for i := 0; i < et.NumField(); i++ {
+		sf := et.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := csvFieldName(sf)
+		if name == "-" {
+			continue
+		}
+		header = append(header, name)
+		indexes = append(indexes, i)
+	}
+
+	records := make([][]string, 0, rv.Len()+1)
+	records = append(records, header)
+	for i := 0; i < rv.Len(); i++ {
+		ev := rv.Index(i)
+		for ev.Kind() == reflect.Pointer {
+			ev = ev.Elem()
+		}
+		record := make([]string, len(indexes))
+		if ev.IsValid() {
+			for j, idx := range indexes {
+				record[j] = fmt.Sprint(ev.Field(idx).Interface())
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func csvFieldName(sf reflect.StructField) string {
+	for _, key := range []string{"csv", "json"} {
+		if tag, ok := sf.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return sf.Name
+}
@@ -0,0 +1,25 @@
+package render
+
+import (
+	"net/http"
+)
+
+// Data 原始字节渲染
+type Data struct {
+	// ContentType 为空时使用 application/octet-stream
+	ContentType string
+	Data        []byte
+}
+
+func (r Data) Render(w http.ResponseWriter) error {
+	_, err := w.Write(r.Data)
+	return err
+}
+
+func (r Data) WriteContentType(w http.ResponseWriter) {
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = MIMEOctet
+	}
+	WriteContentType(w, contentType)
+}
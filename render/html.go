@@ -0,0 +1,36 @@
+package render
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var htmlContentType = MIMEHTML + charsetSuffix
+
+// HTML html 模板渲染，Name 为空时执行 Template 本身
+// 模板先渲染到缓冲区，执行失败时不会写入不完整的内容
+type HTML struct {
+	Template *template.Template
+	Name     string
+	Data     any
+}
+
+func (r HTML) Render(w http.ResponseWriter) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	var err error
+	if r.Name == "" {
+		err = r.Template.Execute(buf, r.Data)
+	} else {
+		err = r.Template.ExecuteTemplate(buf, r.Name, r.Data)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (r HTML) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, htmlContentType)
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/fengjx/go-halo/json"
+)
+
+var (
+	jsonContentType      = MIMEJSON + charsetSuffix
+	jsonASCIIContentType = MIMEJSON
+)
+
+// JSON json 渲染，会转义 html 字符
+type JSON struct {
+	Data any
+}
+
+func (r JSON) Render(w http.ResponseWriter) error {
+	return writeJSON(w, r.Data, true, "")
+}
+
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, jsonContentType)
+}
+
+// IndentedJSON 格式化缩进的 json 渲染
+type IndentedJSON struct {
+	Data any
+}
+
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	return writeJSON(w, r.Data, true, "    ")
+}
+
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, jsonContentType)
+}
+
+// PureJSON 不转义 html 字符的 json 渲染，如 "<" 不会被转义为 "\u003c"
+type PureJSON struct {
+	Data any
+}
+
+func (r PureJSON) Render(w http.ResponseWriter) error {
+	return writeJSON(w, r.Data, false, "")
+}
+
+func (r PureJSON) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, jsonContentType)
+}
+
+// AsciiJSON 非 ASCII 字符转义为 \uXXXX 的 json 渲染
+type AsciiJSON struct {
+	Data any
+}
+
+func (r AsciiJSON) Render(w http.ResponseWriter) error {
+	data, err := json.ToBytes(r.Data)
+	if err != nil {
+		return err
+	}
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	for len(data) > 0 {
+		c, size := utf8.DecodeRune(data)
+		data = data[size:]
+		if c < utf8.RuneSelf {
+			buf.WriteByte(byte(c))
+			continue
+		}
+		if c > 0xFFFF {
+			// 超出 BMP 的字符使用 UTF-16 代理对表示
+			c -= 0x10000
+			fmt.Fprintf(buf, "\\u%04x\\u%04x", 0xD800+(c>>10), 0xDC00+(c&0x3FF))
+			continue
+		}
+		fmt.Fprintf(buf, "\\u%04x", c)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (r AsciiJSON) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, jsonASCIIContentType)
+}
+
+// writeJSON 先编码到缓冲区，成功后一次性写入
+func writeJSON(w http.ResponseWriter, data any, escapeHTML bool, indent string) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	enc := json.NewEncoder(buf)
+	if !escapeHTML {
+		enc.SetEscapeHTML(false)
+	}
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,26 @@
+package render
+
+import (
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPack msgpack 渲染
+type MsgPack struct {
+	Data any
+}
+
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	if err := msgpack.NewEncoder(buf).Encode(r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, MIMEMsgPack)
+}
@@ -0,0 +1,30 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoBuf protobuf 渲染，Data 必须实现 proto.Message
+type ProtoBuf struct {
+	Data any
+}
+
+func (r ProtoBuf) Render(w http.ResponseWriter) error {
+	msg, ok := r.Data.(proto.Message)
+	if !ok {
+		return fmt.Errorf("render: protobuf unsupported type %T", r.Data)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (r ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, MIMEProtoBuf)
+}
@@ -0,0 +1,23 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Redirect 重定向，Code 为 3xx 或 201
+type Redirect struct {
+	Code     int
+	Request  *http.Request
+	Location string
+}
+
+func (r Redirect) Render(w http.ResponseWriter) error {
+	if (r.Code < http.StatusMultipleChoices || r.Code > http.StatusPermanentRedirect) && r.Code != http.StatusCreated {
+		return fmt.Errorf("render: cannot redirect with status code %d", r.Code)
+	}
+	http.Redirect(w, r.Request, r.Location, r.Code)
+	return nil
+}
+
+func (r Redirect) WriteContentType(http.ResponseWriter) {}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEYAML     = "application/yaml"
+	MIMEPlain    = "text/plain"
+	MIMEHTML     = "text/html"
+	MIMEOctet    = "application/octet-stream"
+	MIMECSV      = "text/csv"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEProtoBuf = "application/x-protobuf"
+
+	charsetSuffix = "; charset=utf-8"
+)
+
+// Render 响应内容渲染接口
+type Render interface {
+	// Render 写入响应 body
+	Render(w http.ResponseWriter) error
+	// WriteContentType 设置 Content-Type，已经设置过时不会覆盖
+	WriteContentType(w http.ResponseWriter)
+}
+
+var (
+	_ Render = String{}
+	_ Render = JSON{}
+	_ Render = IndentedJSON{}
+	_ Render = PureJSON{}
+	_ Render = AsciiJSON{}
+	_ Render = XML{}
+	_ Render = YAML{}
+	_ Render = Data{}
+	_ Render = Redirect{}
+	_ Render = CSV{}
+	_ Render = MsgPack{}
+	_ Render = ProtoBuf{}
+	_ Render = HTML{}
+)
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// GetBuffer 从缓冲池获取 bytes.Buffer，使用后需要调用 PutBuffer 归还
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer 归还 bytes.Buffer
+func PutBuffer(buf *bytes.Buffer) {
+	// 避免大对象长期占用内存
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// WriteContentType 设置 Content-Type，已经设置过时不会覆盖
+func WriteContentType(w http.ResponseWriter, contentType string) {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", contentType)
+	}
+}
+
+// BodyAllowedForStatus 判断响应码是否允许写入 body
+func BodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
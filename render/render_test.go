@@ -0,0 +1,114 @@
+package render
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type xmlUser struct {
+	XMLName struct{} `xml:"user"`
+	Name    string   `xml:"name"`
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		render      Render
+		contentType string
+		body        string
+	}{
+		{
+			name:        "string",
+			render:      String{Format: "hello"},
+			contentType: "text/plain; charset=utf-8",
+			body:        "hello",
+		},
+		{
+			name:        "string with args",
+			render:      String{Format: "hello %s %d", Data: []any{"xin", 1}},
+			contentType: "text/plain; charset=utf-8",
+			body:        "hello xin 1",
+		},
+		{
+			name:        "json",
+			render:      JSON{Data: map[string]string{"name": "<b>"}},
+			contentType: "application/json; charset=utf-8",
+			body:        "{\"name\":\"\\u003cb\\u003e\"}\n",
+		},
+		{
+			name:        "pure json",
+			render:      PureJSON{Data: map[string]string{"name": "<b>"}},
+			contentType: "application/json; charset=utf-8",
+			body:        "{\"name\":\"<b>\"}\n",
+		},
+		{
+			name:        "indented json",
+			render:      IndentedJSON{Data: map[string]int{"a": 1}},
+			contentType: "application/json; charset=utf-8",
+			body:        "{\n    \"a\": 1\n}\n",
+		},
+		{
+			name:        "ascii json",
+			render:      AsciiJSON{Data: []string{"小明", "😀"}},
+			contentType: "application/json",
+			body:        `["\u5c0f\u660e","\ud83d\ude00"]`,
+		},
+		{
+			name:        "xml",
+			render:      XML{Data: xmlUser{Name: "foo"}},
+			contentType: "application/xml; charset=utf-8",
+			body:        "<user><name>foo</name></user>",
+		},
+		{
+			name:        "yaml",
+			render:      YAML{Data: map[string]string{"name": "foo"}},
+			contentType: "application/yaml; charset=utf-8",
+			body:        "name: foo\n",
+		},
+		{
+			name:        "data",
+			render:      Data{Data: []byte{1, 2}},
+			contentType: "application/octet-stream",
+			body:        "\x01\x02",
+		},
+		{
+			name:        "html",
+			render:      HTML{Template: template.Must(template.New("page").Parse(`<p>{{.}}</p>`)), Data: "<b>"},
+			contentType: "text/html; charset=utf-8",
+			body:        "<p>&lt;b&gt;</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.render.WriteContentType(w)
+			if err := tt.render.Render(w); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("expected content type %q, got %q", tt.contentType, ct)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/old", nil)
+	w := httptest.NewRecorder()
+	if err := (Redirect{Code: http.StatusFound, Request: r, Location: "/new"}).Render(w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/new" {
+		t.Errorf("unexpected redirect: %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	if err := (Redirect{Code: http.StatusOK, Request: r, Location: "/new"}).Render(httptest.NewRecorder()); err == nil {
+		t.Error("expected error for invalid redirect code")
+	}
+}
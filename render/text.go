@@ -0,0 +1,27 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+)
+
+var plainContentType = MIMEPlain + charsetSuffix
+
+// String 文本渲染，Data 不为空时使用 fmt.Sprintf 格式化
+type String struct {
+	Format string
+	Data   []any
+}
+
+func (r String) Render(w http.ResponseWriter) error {
+	if len(r.Data) > 0 {
+		_, err := fmt.Fprintf(w, r.Format, r.Data...)
+		return err
+	}
+	_, err := w.Write([]byte(r.Format))
+	return err
+}
+
+func (r String) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, plainContentType)
+}
@@ -0,0 +1,27 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+var xmlContentType = MIMEXML + charsetSuffix
+
+// XML xml 渲染
+type XML struct {
+	Data any
+}
+
+func (r XML) Render(w http.ResponseWriter) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	if err := xml.NewEncoder(buf).Encode(r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r XML) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, xmlContentType)
+}
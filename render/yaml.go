@@ -0,0 +1,32 @@
+package render
+
+import (
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yamlContentType = MIMEYAML + charsetSuffix
+
+// YAML yaml 渲染
+type YAML struct {
+	Data any
+}
+
+func (r YAML) Render(w http.ResponseWriter) error {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	enc := yaml.NewEncoder(buf)
+	if err := enc.Encode(r.Data); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r YAML) WriteContentType(w http.ResponseWriter) {
+	WriteContentType(w, yamlContentType)
+}
@@ -0,0 +1,86 @@
+package xin
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// SPAOptions 单页应用配置
+type SPAOptions struct {
+	// Index 入口文件，默认 index.html
+	Index string
+	// ExcludePrefixes 不回退到入口文件的请求路径前缀，如 "/api/"，匹配完整的请求路径
+	ExcludePrefixes []string
+}
+
+// SPA 注册单页应用，存在的文件正常返回，前端路由回退到入口文件
+// 带扩展名的路径和 ExcludePrefixes 中的路径不存在时返回 404
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	sub, _ := fs.Sub(dist, "dist")
+//	mux.SPA("/", sub, xin.SPAOptions{ExcludePrefixes: []string{"/api/"}})
+func (mux *Mux) SPA(pattern string, fsys fs.FS, opts SPAOptions) *Mux {
+	_, prefix := splitPattern(pattern)
+	mux.ServeMux.Handle(pattern, SPAHandler(prefix, fsys, opts))
+	return mux
+}
+
+// SPAHandler 返回单页应用的 http.Handler，参考 Mux.SPA
+func SPAHandler(prefix string, fsys fs.FS, opts SPAOptions) http.Handler {
+	if opts.Index == "" {
+		opts.Index = indexPage
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		for _, exclude := range opts.ExcludePrefixes {
+			if strings.HasPrefix(r.URL.Path, exclude) {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		upath, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean("/"+upath), "/")
+		if name != "" {
+			if file, ok := spaFile(fsys, name, opts.Index); ok {
+				FileFromFS(w, r, fsys, file)
+				return
+			}
+			if path.Ext(name) != "" {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		// 入口文件不缓存，保证发布新版本后客户端能及时更新
+		w.Header().Set("Cache-Control", "no-cache")
+		FileFromFS(w, r, fsys, opts.Index)
+	})
+}
+
+// spaFile 查找请求对应的文件，目录使用目录下的入口文件
+func spaFile(fsys fs.FS, name, index string) (string, bool) {
+	stat, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", false
+	}
+	if !stat.IsDir() {
+		return name, true
+	}
+	name = path.Join(name, index)
+	if stat, err = fs.Stat(fsys, name); err != nil || stat.IsDir() {
+		return "", false
+	}
+	return name, true
+}
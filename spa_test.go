@@ -0,0 +1,61 @@
+package xin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fengjx/xin"
+)
+
+func TestSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":         {Data: []byte("spa index")},
+		"assets/app.js":      {Data: []byte("console.log('xin')")},
+		"docs/index.html":    {Data: []byte("docs index")},
+		"assets/empty/.keep": {Data: []byte("")},
+	}
+	mux := xin.NewMux()
+	mux.GET("/api/ping", func(w http.ResponseWriter, r *http.Request) {
+		xin.WriteString(w, http.StatusOK, "pong")
+	})
+	mux.SPA("/", fsys, xin.SPAOptions{ExcludePrefixes: []string{"/api/"}})
+
+	admin := xin.NewMux()
+	admin.SPA("/admin/", fsys, xin.SPAOptions{})
+
+	tests := []struct {
+		name   string
+		mux    http.Handler
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"root", mux, http.MethodGet, "/", http.StatusOK, "spa index"},
+		{"asset", mux, http.MethodGet, "/assets/app.js", http.StatusOK, "console.log('xin')"},
+		{"client route", mux, http.MethodGet, "/users/1/edit", http.StatusOK, "spa index"},
+		{"directory index", mux, http.MethodGet, "/docs/", http.StatusOK, "docs index"},
+		{"directory without index", mux, http.MethodGet, "/assets/empty", http.StatusOK, "spa index"},
+		{"missing asset", mux, http.MethodGet, "/assets/missing.js", http.StatusNotFound, ""},
+		{"api route", mux, http.MethodGet, "/api/ping", http.StatusOK, "pong"},
+		{"missing api", mux, http.MethodGet, "/api/unknown", http.StatusNotFound, ""},
+		{"post", mux, http.MethodPost, "/users", http.StatusMethodNotAllowed, ""},
+		{"prefix root", admin, http.MethodGet, "/admin/", http.StatusOK, "spa index"},
+		{"prefix client route", admin, http.MethodGet, "/admin/settings", http.StatusOK, "spa index"},
+		{"prefix asset", admin, http.MethodGet, "/admin/assets/app.js", http.StatusOK, "console.log('xin')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package xin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fengjx/go-halo/json"
+)
+
+// DefaultSSEHeartbeat 默认心跳间隔
+const DefaultSSEHeartbeat = 15 * time.Second
+
+var (
+	// ErrSSEClosed 事件流已关闭
+	ErrSSEClosed = errors.New("xin: sse stream closed")
+	// ErrSSESlowConsumer 客户端消费过慢，被广播中心移除
+	ErrSSESlowConsumer = errors.New("xin: sse slow consumer evicted")
+	// ErrSSEHubClosed 广播中心已关闭
+	ErrSSEHubClosed = errors.New("xin: sse hub closed")
+)
+
+var sseFieldReplacer = strings.NewReplacer("\r\n", "", "\r", "", "\n", "")
+
+// SSEEvent Server-Sent Events 事件
+type SSEEvent struct {
+	// ID 事件 ID，客户端重连时通过 Last-Event-ID 请求头带回
+	ID string
+	// Event 事件类型，为空时客户端触发 message 事件
+	Event string
+	// Data 事件数据，string 和 []byte 原样写入，其他类型编码为 json
+	Data any
+	// Retry 客户端断线重连的等待时间
+	Retry time.Duration
+}
+
+// SSEOptions 事件流配置
+type SSEOptions struct {
+	// Heartbeat 心跳间隔，空闲时发送注释行保持连接，默认 DefaultSSEHeartbeat，小于 0 表示不发送心跳
+	Heartbeat time.Duration
+	// Retry 建立连接时发送给客户端的重连等待时间，0 表示不发送
+	Retry time.Duration
+}
+
+// SSEStream Server-Sent Events 事件流
+type SSEStream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	ctx         context.Context
+	cancel      context.CancelFunc
+	lastEventID string
+	mtx         sync.Mutex
+	closed      bool
+	heartbeat   sync.WaitGroup
+}
+
+// SSE 创建 Server-Sent Events 事件流，使用默认配置
+//
+//	stream, err := xin.SSE(w, r)
+//	if err != nil {
+//		return
+//	}
+//	defer stream.Close()
+//	for {
+//		select {
+//		case <-stream.Done():
+//			return
+//		case msg := <-messages:
+//			stream.Send(xin.SSEEvent{Event: "message", Data: msg})
+//		}
+//	}
+func SSE(w http.ResponseWriter, r *http.Request) (*SSEStream, error) {
+	return SSEWith(w, r, SSEOptions{})
+}
+
+// SSEWith 创建 Server-Sent Events 事件流，会立即写入响应头
+// ResponseWriter 不支持 http.Flusher 时返回 http.ErrNotSupported，此时不会写入任何响应
+// handler 返回前需要调用 Close 停止心跳
+func SSEWith(w http.ResponseWriter, r *http.Request, opts SSEOptions) (*SSEStream, error) {
+	if !canFlush(w) {
+		return nil, http.ErrNotSupported
+	}
+	if opts.Heartbeat == 0 {
+		opts.Heartbeat = DefaultSSEHeartbeat
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// 禁用 nginx 缓冲
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	s := &SSEStream{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		ctx:         ctx,
+		cancel:      cancel,
+		lastEventID: r.Header.Get("Last-Event-ID"),
+	}
+	if opts.Retry > 0 {
+		s.mtx.Lock()
+		_, _ = w.Write([]byte("retry: " + strconv.FormatInt(opts.Retry.Milliseconds(), 10) + "\n\n"))
+		s.mtx.Unlock()
+	}
+	if err := s.rc.Flush(); err != nil {
+		cancel()
+		return nil, err
+	}
+	if opts.Heartbeat > 0 {
+		s.heartbeat.Add(1)
+		go s.keepalive(opts.Heartbeat)
+	}
+	return s, nil
+}
+
+// canFlush 判断 w 是否支持 flush，查找方式与 http.ResponseController 一致
+// ResponseController.Flush 会直接写入状态码，所以在写入响应头之前单独检查
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case interface{ FlushError() error }, http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// LastEventID 返回客户端重连时带回的最后一个事件 ID
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Context 返回事件流的 context，客户端断开或调用 Close 后结束
+func (s *SSEStream) Context() context.Context {
+	return s.ctx
+}
+
+// Done 客户端断开或调用 Close 后关闭
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send 发送事件
+func (s *SSEStream) Send(ev SSEEvent) error {
+	buf := new(bytes.Buffer)
+	if ev.ID != "" {
+		buf.WriteString("id: " + sseFieldReplacer.Replace(ev.ID) + "\n")
+	}
+	if ev.Event != "" {
+		buf.WriteString("event: " + sseFieldReplacer.Replace(ev.Event) + "\n")
+	}
+	if ev.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	var data []byte
+	switch v := ev.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.ToBytes(v); err != nil {
+			return err
+		}
+	}
+	// 多行数据每行都需要 data: 前缀
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// SendData 发送只有数据的 message 事件
+func (s *SSEStream) SendData(data any) error {
+	return s.Send(SSEEvent{Data: data})
+}
+
+// Comment 发送注释行，客户端会忽略
+func (s *SSEStream) Comment(text string) error {
+	return s.write([]byte(": " + sseFieldReplacer.Replace(text) + "\n\n"))
+}
+
+// Close 关闭事件流并等待心跳停止，不会关闭底层连接
+func (s *SSEStream) Close() error {
+	s.mtx.Lock()
+	s.closed = true
+	s.mtx.Unlock()
+	s.cancel()
+	s.heartbeat.Wait()
+	return nil
+}
+
+func (s *SSEStream) write(b []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return ErrSSEClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(b); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *SSEStream) keepalive(interval time.Duration) {
+	defer s.heartbeat.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Comment("ping"); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package xin
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultSSEBufferSize 每个客户端默认的事件缓冲数量
+	DefaultSSEBufferSize = 16
+	// DefaultSSEHistorySize 每个主题默认保留的历史事件数量
+	DefaultSSEHistorySize = 100
+)
+
+// SSEHubOptions 广播中心配置
+type SSEHubOptions struct {
+	// BufferSize 每个客户端的事件缓冲数量，缓冲满时客户端会被移除，默认 DefaultSSEBufferSize
+	BufferSize int
+	// HistorySize 每个主题保留的历史事件数量，用于客户端通过 Last-Event-ID 断线续传
+	// 默认 DefaultSSEHistorySize，小于 0 表示不保留
+	HistorySize int
+	// Stream 客户端事件流配置
+	Stream SSEOptions
+}
+
+type sseHistoryEvent struct {
+	seq uint64
+	ev  SSEEvent
+}
+
+type sseClient struct {
+	topics []string
+	ch     chan SSEEvent
+	err    error
+}
+
+// SSEHub 按主题广播 Server-Sent Events
+//
+//	hub := xin.NewSSEHub(xin.SSEHubOptions{})
+//	mux.GET("/events", func(w http.ResponseWriter, r *http.Request) {
+//		hub.Serve(w, r, "orders")
+//	})
+//	hub.Publish("orders", xin.SSEEvent{Event: "created", Data: order})
+type SSEHub struct {
+	opts    SSEHubOptions
+	mtx     sync.Mutex
+	seq     uint64
+	closed  bool
+	topics  map[string]map[*sseClient]struct{}
+	history map[string][]sseHistoryEvent
+}
+
+// NewSSEHub 创建广播中心
+func NewSSEHub(opts SSEHubOptions) *SSEHub {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultSSEBufferSize
+	}
+	if opts.HistorySize == 0 {
+		opts.HistorySize = DefaultSSEHistorySize
+	}
+	return &SSEHub{
+		opts:    opts,
+		topics:  make(map[string]map[*sseClient]struct{}),
+		history: make(map[string][]sseHistoryEvent),
+	}
+}
+
+// Publish 向主题的所有客户端广播事件，不会阻塞
+// 事件 ID 由广播中心按递增序号分配，会覆盖 ev.ID
+// 缓冲已满的客户端会被移除，它的 Serve 返回 ErrSSESlowConsumer
+func (h *SSEHub) Publish(topic string, ev SSEEvent) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.closed {
+		return
+	}
+	h.seq++
+	ev.ID = strconv.FormatUint(h.seq, 10)
+	if h.opts.HistorySize > 0 {
+		history := append(h.history[topic], sseHistoryEvent{seq: h.seq, ev: ev})
+		if len(history) > h.opts.HistorySize {
+			history = slices.Delete(history, 0, len(history)-h.opts.HistorySize)
+		}
+		h.history[topic] = history
+	}
+	for c := range h.topics[topic] {
+		select {
+		case c.ch <- ev:
+		default:
+			h.evict(c, ErrSSESlowConsumer)
+		}
+	}
+}
+
+// Serve 建立事件流并订阅主题，阻塞直到客户端断开、被移除或广播中心关闭
+// 请求带有 Last-Event-ID 时会先补发历史中之后的事件
+func (h *SSEHub) Serve(w http.ResponseWriter, r *http.Request, topics ...string) error {
+	stream, err := SSEWith(w, r, h.opts.Stream)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	c, replay, err := h.subscribe(topics, stream.LastEventID())
+	if err != nil {
+		return err
+	}
+	defer h.unsubscribe(c)
+	for _, ev := range replay {
+		if err = stream.Send(ev); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Done():
+			return nil
+		case ev, ok := <-c.ch:
+			if !ok {
+				return c.err
+			}
+			if err = stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Clients 返回主题的客户端数量
+func (h *SSEHub) Clients(topic string) int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return len(h.topics[topic])
+}
+
+// Close 关闭广播中心，所有客户端的 Serve 返回 ErrSSEHubClosed
+func (h *SSEHub) Close() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.closed = true
+	for _, clients := range h.topics {
+		for c := range clients {
+			h.evict(c, ErrSSEHubClosed)
+		}
+	}
+}
+
+func (h *SSEHub) subscribe(topics []string, lastEventID string) (*sseClient, []SSEEvent, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.closed {
+		return nil, nil, ErrSSEHubClosed
+	}
+	c := &sseClient{
+		topics: slices.Compact(slices.Sorted(slices.Values(topics))),
+		ch:     make(chan SSEEvent, h.opts.BufferSize),
+	}
+	for _, topic := range c.topics {
+		clients, ok := h.topics[topic]
+		if !ok {
+			clients = make(map[*sseClient]struct{})
+			h.topics[topic] = clients
+		}
+		clients[c] = struct{}{}
+	}
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return c, nil, nil
+	}
+	// 在同一个锁内注册客户端和收集历史事件，保证补发的事件不重复也不遗漏
+	var replay []sseHistoryEvent
+	for _, topic := range c.topics {
+		for _, item := range h.history[topic] {
+			if item.seq > last {
+				replay = append(replay, item)
+			}
+		}
+	}
+	slices.SortFunc(replay, func(a, b sseHistoryEvent) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	events := make([]SSEEvent, 0, len(replay))
+	for _, item := range replay {
+		events = append(events, item.ev)
+	}
+	return c, events, nil
+}
+
+func (h *SSEHub) unsubscribe(c *sseClient) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.evict(c, nil)
+}
+
+// evict 移除客户端并关闭它的 channel，调用方需要持有锁
+func (h *SSEHub) evict(c *sseClient, err error) {
+	removed := false
+	for _, topic := range c.topics {
+		clients := h.topics[topic]
+		if _, ok := clients[c]; !ok {
+			continue
+		}
+		removed = true
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+	if removed {
+		c.err = err
+		close(c.ch)
+	}
+}
@@ -0,0 +1,146 @@
+package xin_test
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fengjx/xin"
+)
+
+func TestSSE(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Last-Event-ID", "41")
+	stream, err := xin.SSEWith(w, r, xin.SSEOptions{Heartbeat: -1, Retry: 3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.LastEventID() != "41" {
+		t.Errorf("unexpected last event id %q", stream.LastEventID())
+	}
+	if err = stream.Send(xin.SSEEvent{ID: "42", Event: "update\n", Data: "line1\nline2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.SendData(xin.Map{"name": "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = stream.Comment("ping"); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	if err = stream.SendData("closed"); !errors.Is(err, xin.ErrSSEClosed) {
+		t.Errorf("expected ErrSSEClosed, got %v", err)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	want := "retry: 3000\n\n" +
+		"id: 42\nevent: update\ndata: line1\ndata: line2\n\n" +
+		"data: {\"name\":\"foo\"}\n\n" +
+		": ping\n\n"
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+}
+
+func TestSSENotSupported(t *testing.T) {
+	rec := httptest.NewRecorder()
+	// 只实现 http.ResponseWriter，不支持 flush
+	w := struct{ http.ResponseWriter }{rec}
+	_, err := xin.SSE(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if !errors.Is(err, http.ErrNotSupported) {
+		t.Fatalf("expected http.ErrNotSupported, got %v", err)
+	}
+	if rec.Header().Get("Content-Type") != "" {
+		t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func readSSEEvent(t *testing.T, br *bufio.Reader) map[string]string {
+	t.Helper()
+	ev := make(map[string]string)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(ev) == 0 {
+				continue
+			}
+			return ev
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		k, v, _ := strings.Cut(line, ": ")
+		ev[k] = v
+	}
+}
+
+func TestSSEHub(t *testing.T) {
+	hub := xin.NewSSEHub(xin.SSEHubOptions{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.Serve(w, r, "orders")
+	}))
+	defer srv.Close()
+	defer hub.Close()
+
+	connect := func(lastEventID string) (*http.Response, *bufio.Reader) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, bufio.NewReader(resp.Body)
+	}
+	waitClients := func(n int) {
+		for i := 0; i < 100 && hub.Clients("orders") != n; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if hub.Clients("orders") != n {
+			t.Fatalf("expected %d clients, got %d", n, hub.Clients("orders"))
+		}
+	}
+
+	resp, br := connect("")
+	waitClients(1)
+	hub.Publish("orders", xin.SSEEvent{Event: "created", Data: "1"})
+	hub.Publish("users", xin.SSEEvent{Data: "ignored"})
+	hub.Publish("orders", xin.SSEEvent{Event: "created", Data: "2"})
+
+	ev := readSSEEvent(t, br)
+	if ev["id"] != "1" || ev["data"] != "1" || ev["event"] != "created" {
+		t.Errorf("unexpected event %v", ev)
+	}
+	ev = readSSEEvent(t, br)
+	if ev["id"] != "3" || ev["data"] != "2" {
+		t.Errorf("unexpected event %v", ev)
+	}
+	resp.Body.Close()
+	waitClients(0)
+
+	// 断线续传，补发 id 1 之后的事件
+	hub.Publish("orders", xin.SSEEvent{Data: "3"})
+	resp, br = connect("1")
+	defer resp.Body.Close()
+	for _, want := range []string{"2", "3"} {
+		if ev = readSSEEvent(t, br); ev["data"] != want {
+			t.Errorf("expected replay data %q, got %v", want, ev)
+		}
+	}
+}
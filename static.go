@@ -0,0 +1,206 @@
+package xin
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ImmutableCacheControl 带内容指纹的文件使用的 Cache-Control，缓存一年且不需要重新验证
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// DefaultFingerprint 匹配文件名中的十六进制内容指纹，如 app.3f2a1b9c.js、chunk-5f3c9a1d.css
+var DefaultFingerprint = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[0-9A-Za-z]+$`)
+
+// StaticOptions 静态文件服务配置
+type StaticOptions struct {
+	// HashETag 启动时计算所有文件内容的 sha256 作为强 ETag
+	// embed.FS 的修改时间为零值，需要使用 ETag 才能正确处理 If-None-Match
+	HashETag bool
+	// CacheControl 按路径设置 Cache-Control，按顺序匹配，优先于 Fingerprint 和 IndexCacheControl
+	CacheControl []CacheControlRule
+	// Fingerprint 匹配带内容指纹的文件名，匹配的文件使用 ImmutableCacheControl，默认 DefaultFingerprint
+	Fingerprint *regexp.Regexp
+	// IndexCacheControl index.html 的 Cache-Control，默认 no-cache
+	IndexCacheControl string
+	// DefaultCacheControl 其他文件的 Cache-Control，默认不设置
+	DefaultCacheControl string
+	// Listing 目录列表配置，目录下没有 index.html 时返回目录列表
+	Listing ListingOptions
+}
+
+// CacheControlRule 路径匹配的 Cache-Control 规则
+type CacheControlRule struct {
+	// Pattern path.Match 规则，匹配去掉前缀后的文件路径，如 "assets/*.js"
+	// 不包含 / 时只匹配文件名，如 "*.woff2"
+	Pattern string
+	// Value Cache-Control 的值
+	Value string
+}
+
+func (opts StaticOptions) withDefaults() StaticOptions {
+	if opts.Fingerprint == nil {
+		opts.Fingerprint = DefaultFingerprint
+	}
+	if opts.IndexCacheControl == "" {
+		opts.IndexCacheControl = "no-cache"
+	}
+	return opts
+}
+
+// staticHandler 在 http.FileServer 的基础上处理预压缩文件、ETag 和 Cache-Control
+type staticHandler struct {
+	fsys       fs.FS
+	fileServer http.Handler
+	opts       StaticOptions
+	// etags 文件路径对应的强 ETag，启动后只读
+	etags map[string]string
+}
+
+func newStaticHandler(fsys fs.FS, opts StaticOptions) *staticHandler {
+	h := &staticHandler{
+		fsys:       fsys,
+		fileServer: http.FileServerFS(fsys),
+		opts:       opts,
+	}
+	if opts.HashETag {
+		h.etags = hashFiles(fsys)
+	}
+	return h
+}
+
+// serve upath 为去掉前缀后的请求路径，找不到对应文件时交给 fallback 处理
+func (h *staticHandler) serve(w http.ResponseWriter, r *http.Request, upath string, fallback http.Handler) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		if name, ok := staticFile(h.fsys, upath); ok {
+			if cc := h.cacheControl(name); cc != "" {
+				w.Header().Set("Cache-Control", cc)
+			}
+			if servePrecompressed(w, r, h.fsys, name, h.etags) {
+				return
+			}
+			if etag, ok := h.etags[name]; ok {
+				w.Header().Set("ETag", etag)
+			}
+		} else if name, ok := staticDir(h.fsys, upath); ok {
+			serveListing(w, r, h.fsys, name, h.opts.Listing)
+			return
+		}
+	}
+	fallback.ServeHTTP(w, r)
+}
+
+func (h *staticHandler) cacheControl(name string) string {
+	base := path.Base(name)
+	for _, rule := range h.opts.CacheControl {
+		target := name
+		if !strings.Contains(rule.Pattern, "/") {
+			target = base
+		}
+		if ok, _ := path.Match(rule.Pattern, target); ok {
+			return rule.Value
+		}
+	}
+	if h.opts.Fingerprint != nil && h.opts.Fingerprint.MatchString(base) {
+		return ImmutableCacheControl
+	}
+	if base == indexPage && h.opts.IndexCacheControl != "" {
+		return h.opts.IndexCacheControl
+	}
+	return h.opts.DefaultCacheControl
+}
+
+// staticFile 返回请求路径对应的文件，目录需要以 / 结尾，使用目录下的 index.html
+func staticFile(fsys fs.FS, upath string) (string, bool) {
+	name := strings.TrimPrefix(path.Clean("/"+upath), "/")
+	if name != "" {
+		stat, err := fs.Stat(fsys, name)
+		if err != nil {
+			return "", false
+		}
+		if !stat.IsDir() {
+			if stat.Name() == path.Base(name) {
+				return name, true
+			}
+			// onlyFilesFS 打开目录时直接返回 index.html
+			return path.Join(name, indexPage), true
+		}
+		// 目录不以 / 结尾时交给 http.FileServer 重定向
+		if !strings.HasSuffix(upath, "/") {
+			return "", false
+		}
+	}
+	name = path.Join(name, indexPage)
+	if stat, err := fs.Stat(fsys, name); err != nil || stat.IsDir() {
+		return "", false
+	}
+	return name, true
+}
+
+// staticDir 返回以 / 结尾的请求路径对应的目录，onlyFilesFS 不会返回目录
+func staticDir(fsys fs.FS, upath string) (string, bool) {
+	if !strings.HasSuffix(upath, "/") {
+		return "", false
+	}
+	name := strings.TrimPrefix(path.Clean("/"+upath), "/")
+	if name == "" {
+		name = "."
+	}
+	stat, err := fs.Stat(fsys, name)
+	if err != nil || !stat.IsDir() {
+		return "", false
+	}
+	return name, true
+}
+
+// hashFiles 计算所有文件内容的 sha256，无法读取的文件不设置 ETag
+func hashFiles(fsys fs.FS) map[string]string {
+	etags := make(map[string]string)
+	_ = fs.WalkDir(walkableFS(fsys), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if etag, err := hashFile(fsys, name); err == nil {
+			etags[name] = etag
+		}
+		return nil
+	})
+	return etags
+}
+
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil)[:16]), nil
+}
+
+// walkableFS onlyFilesFS 打开目录时返回 index.html，遍历时需要使用原始的文件系统
+func walkableFS(fsys fs.FS) fs.FS {
+	if cfs, ok := fsys.(*CacheFS); ok {
+		return walkableFS(cfs.fsys)
+	}
+	ofs, ok := fsys.(*onlyFilesFS)
+	if !ok {
+		return fsys
+	}
+	if ofs.root == "" {
+		return ofs.fs
+	}
+	sub, err := fs.Sub(ofs.fs, ofs.root)
+	if err != nil {
+		return fsys
+	}
+	return sub
+}
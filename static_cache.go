@@ -0,0 +1,253 @@
+package xin
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCacheMaxBytes 缓存默认最大字节数
+	DefaultCacheMaxBytes = 64 << 20
+	// DefaultCacheMaxEntries 缓存默认最大文件数
+	DefaultCacheMaxEntries = 10000
+	// DefaultCacheMaxFileSize 默认只缓存不超过该大小的文件
+	DefaultCacheMaxFileSize = 64 << 10
+)
+
+// CacheFSOptions 文件缓存配置
+type CacheFSOptions struct {
+	// MaxBytes 缓存文件内容的最大字节数，默认 DefaultCacheMaxBytes
+	MaxBytes int64
+	// MaxEntries 缓存的最大文件数，默认 DefaultCacheMaxEntries
+	MaxEntries int
+	// MaxFileSize 只缓存不超过该大小的文件，默认 DefaultCacheMaxFileSize
+	MaxFileSize int64
+	// PollInterval 检查已缓存文件修改时间和大小的间隔，发生变化的文件会被移除，0 表示不检查
+	PollInterval time.Duration
+}
+
+// CacheFSStats 缓存统计
+type CacheFSStats struct {
+	Entries   int
+	Bytes     int64
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// CacheFS 在内存中缓存小文件的内容和文件信息，按 LRU 淘汰
+// 适用于 Dir 等磁盘文件系统，目录和大文件不会缓存
+//
+//	cfs := xin.NewCacheFS(xin.Dir("./static", false), xin.CacheFSOptions{PollInterval: 5 * time.Second})
+//	defer cfs.Close()
+//	mux.StaticFS("/static/", cfs)
+type CacheFS struct {
+	fsys fs.FS
+	opts CacheFSOptions
+
+	mtx     sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   CacheFSStats
+
+	closeOnce sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+type cacheEntry struct {
+	name string
+	info fs.FileInfo
+	data []byte
+}
+
+// NewCacheFS 创建文件缓存，设置了 PollInterval 时需要调用 Close 停止检查
+func NewCacheFS(fsys fs.FS, opts CacheFSOptions) *CacheFS {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultCacheMaxBytes
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultCacheMaxEntries
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = DefaultCacheMaxFileSize
+	}
+	c := &CacheFS{
+		fsys:    fsys,
+		opts:    opts,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		done:    make(chan struct{}),
+	}
+	if opts.PollInterval > 0 {
+		c.wg.Add(1)
+		go c.poll()
+	}
+	return c
+}
+
+// Open 实现 fs.FS，命中缓存时返回内存中的文件
+func (c *CacheFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	c.mtx.Lock()
+	if e, ok := c.entries[name]; ok {
+		c.lru.MoveToFront(e)
+		c.stats.Hits++
+		entry := e.Value.(*cacheEntry)
+		c.mtx.Unlock()
+		return newCachedFile(entry), nil
+	}
+	c.stats.Misses++
+	c.mtx.Unlock()
+
+	f, err := c.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || info.Size() > c.opts.MaxFileSize {
+		return f, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(f, c.opts.MaxFileSize+1))
+	f.Close()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	entry := &cacheEntry{name: name, info: info, data: data}
+	// 读取期间文件发生了变化，不缓存
+	if int64(len(data)) == info.Size() {
+		c.add(entry)
+	}
+	return newCachedFile(entry), nil
+}
+
+// Stat 实现 fs.StatFS，命中缓存时不访问文件系统
+func (c *CacheFS) Stat(name string) (fs.FileInfo, error) {
+	c.mtx.Lock()
+	if e, ok := c.entries[name]; ok {
+		c.lru.MoveToFront(e)
+		info := e.Value.(*cacheEntry).info
+		c.mtx.Unlock()
+		return info, nil
+	}
+	c.mtx.Unlock()
+	return fs.Stat(c.fsys, name)
+}
+
+// Invalidate 移除缓存的文件，name 为空时清空缓存
+func (c *CacheFS) Invalidate(name string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if name == "" {
+		c.lru.Init()
+		clear(c.entries)
+		c.stats.Bytes = 0
+		return
+	}
+	if e, ok := c.entries[name]; ok {
+		c.remove(e)
+	}
+}
+
+// Stats 返回缓存统计
+func (c *CacheFS) Stats() CacheFSStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// Close 停止检查文件变化
+func (c *CacheFS) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+	return nil
+}
+
+func (c *CacheFS) add(entry *cacheEntry) {
+	size := int64(len(entry.data))
+	if size > c.opts.MaxBytes {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if e, ok := c.entries[entry.name]; ok {
+		c.remove(e)
+	}
+	c.entries[entry.name] = c.lru.PushFront(entry)
+	c.stats.Bytes += size
+	for c.stats.Bytes > c.opts.MaxBytes || c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove 调用方需要持有锁
+func (c *CacheFS) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry)
+	delete(c.entries, entry.name)
+	c.stats.Bytes -= int64(len(entry.data))
+}
+
+func (c *CacheFS) poll() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.check()
+		}
+	}
+}
+
+// check 移除修改时间或大小发生变化、以及已经删除的文件
+func (c *CacheFS) check() {
+	c.mtx.Lock()
+	entries := make([]*cacheEntry, 0, c.lru.Len())
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		entries = append(entries, e.Value.(*cacheEntry))
+	}
+	c.mtx.Unlock()
+	for _, entry := range entries {
+		info, err := fs.Stat(c.fsys, entry.name)
+		if err == nil && info.ModTime().Equal(entry.info.ModTime()) && info.Size() == entry.info.Size() {
+			continue
+		}
+		c.mtx.Lock()
+		// 检查期间可能已经重新缓存
+		if e, ok := c.entries[entry.name]; ok && e.Value.(*cacheEntry) == entry {
+			c.remove(e)
+		}
+		c.mtx.Unlock()
+	}
+}
+
+// cachedFile 缓存文件的只读副本，支持 Seek 和 ReadAt
+type cachedFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func newCachedFile(entry *cacheEntry) *cachedFile {
+	return &cachedFile{Reader: bytes.NewReader(entry.data), info: entry.info}
+}
+
+func (f *cachedFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *cachedFile) Close() error {
+	return nil
+}
@@ -0,0 +1,204 @@
+package xin
+
+import (
+	"cmp"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fengjx/xin/render"
+)
+
+// ListingOptions 目录列表配置，只对可以列出目录的文件系统生效，如 Dir(root, true)
+type ListingOptions struct {
+	// ShowHidden 显示以 . 开头的文件和目录
+	ShowHidden bool
+	// Template 自定义 html 模板，模板数据为 *DirListing
+	Template *template.Template
+}
+
+// DirListing 目录列表，请求头 Accept 为 application/json 时以 json 返回
+// 通过查询参数排序：sort=name|size|time，order=asc|desc，目录始终排在文件前面
+type DirListing struct {
+	// Path 目录路径，以 / 结尾
+	Path string `json:"path"`
+	// Sort 排序字段
+	Sort string `json:"sort"`
+	// Order 排序方向
+	Order string `json:"order"`
+	// Items 目录下的文件和目录
+	Items []DirItem `json:"items"`
+}
+
+// DirItem 目录列表中的文件或目录
+type DirItem struct {
+	Name    string    `json:"name"`
+	URL     string    `json:"url"`
+	IsDir   bool      `json:"is_dir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// HumanSize 返回易读的文件大小，如 1.5 KB
+func (item DirItem) HumanSize() string {
+	if item.IsDir {
+		return "-"
+	}
+	const unit = 1024
+	if item.Size < unit {
+		return fmt.Sprintf("%d B", item.Size)
+	}
+	div, exp := int64(unit), 0
+	for n := item.Size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(item.Size)/float64(div), "KMGTPE"[exp])
+}
+
+// SortURL 返回按 field 排序的链接，当前已按 field 升序时返回降序链接
+func (l *DirListing) SortURL(field string) string {
+	order := "asc"
+	if l.Sort == field && l.Order == "asc" {
+		order = "desc"
+	}
+	return "?sort=" + field + "&order=" + order
+}
+
+var defaultListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Index of {{.Path}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;margin:2em;color:#24292f}
+h1{font-size:1.4em;font-weight:500;word-break:break-all}
+table{border-collapse:collapse;width:100%}
+th,td{padding:.4em .8em;text-align:left;border-bottom:1px solid #eaecef}
+th a{color:inherit}
+td.size,td.time{white-space:nowrap;color:#57606a}
+a{color:#0969da;text-decoration:none}
+a:hover{text-decoration:underline}
+</style>
+</head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<thead><tr>
+<th><a href="{{.SortURL "name"}}">Name</a></th>
+<th><a href="{{.SortURL "size"}}">Size</a></th>
+<th><a href="{{.SortURL "time"}}">Modified</a></th>
+</tr></thead>
+<tbody>
+{{- if ne .Path "/"}}
+<tr><td><a href="../">../</a></td><td class="size">-</td><td class="time"></td></tr>
+{{- end}}
+{{- range .Items}}
+<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td class="size">{{.HumanSize}}</td><td class="time">{{.ModTime.Format "2006-01-02 15:04:05"}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// serveListing 返回目录列表，name 为目录在文件系统中的路径
+func serveListing(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, opts ListingOptions) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	listing := &DirListing{
+		Path:  path.Clean("/"+r.URL.Path) + "/",
+		Sort:  r.URL.Query().Get("sort"),
+		Order: r.URL.Query().Get("order"),
+		Items: make([]DirItem, 0, len(entries)),
+	}
+	if listing.Path == "//" {
+		listing.Path = "/"
+	}
+	for _, entry := range entries {
+		itemName := entry.Name()
+		if !opts.ShowHidden && strings.HasPrefix(itemName, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		item := DirItem{
+			Name:    itemName,
+			URL:     (&url.URL{Path: itemName}).String(),
+			IsDir:   entry.IsDir(),
+			ModTime: info.ModTime(),
+		}
+		if item.IsDir {
+			item.URL += "/"
+		} else {
+			item.Size = info.Size()
+		}
+		listing.Items = append(listing.Items, item)
+	}
+	sortListing(listing)
+
+	addVary(w.Header(), "Accept")
+	if selectMediaType(r.Header.Get("Accept"), []string{render.MIMEHTML, render.MIMEJSON}) == 1 {
+		_ = WriteJSON(w, http.StatusOK, listing)
+		return
+	}
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = defaultListingTemplate
+	}
+	buf := render.GetBuffer()
+	defer render.PutBuffer(buf)
+	if err = tmpl.Execute(buf, listing); err != nil {
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	_ = Render(w, http.StatusOK, render.Data{
+		ContentType: render.MIMEHTML + "; charset=utf-8",
+		Data:        buf.Bytes(),
+	})
+}
+
+func sortListing(l *DirListing) {
+	switch l.Sort {
+	case "name", "size", "time":
+	default:
+		l.Sort = "name"
+	}
+	if l.Order != "desc" {
+		l.Order = "asc"
+	}
+	slices.SortStableFunc(l.Items, func(a, b DirItem) int {
+		if a.IsDir != b.IsDir {
+			if a.IsDir {
+				return -1
+			}
+			return 1
+		}
+		var c int
+		switch l.Sort {
+		case "size":
+			c = cmp.Compare(a.Size, b.Size)
+		case "time":
+			c = a.ModTime.Compare(b.ModTime)
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		if l.Order == "desc" {
+			c = -c
+		}
+		return c
+	})
+}
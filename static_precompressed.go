@@ -0,0 +1,113 @@
+package xin
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// precompressedEncodings 预压缩文件的编码和扩展名，按服务端偏好排序
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"zstd", ".zst"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed 查找与 Accept-Encoding 匹配的预压缩文件，如 app.js.br、app.js.gz
+// 找到时返回压缩后的内容，Content-Type 使用原文件的类型，Range 作用在压缩后的内容上
+// name 为 staticFile 返回的文件路径，etags 中存在压缩文件时设置对应的 ETag
+// 没有预压缩文件或客户端不支持时返回 false，由调用方返回原文件
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, etags map[string]string) bool {
+	var available []string
+	for _, pe := range precompressedEncodings {
+		if stat, err := fs.Stat(fsys, name+pe.ext); err == nil && !stat.IsDir() {
+			available = append(available, pe.encoding)
+		}
+	}
+	if len(available) == 0 {
+		return false
+	}
+	addVary(w.Header(), "Accept-Encoding")
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), available)
+	if encoding == "" {
+		return false
+	}
+	var ext string
+	for _, pe := range precompressedEncodings {
+		if pe.encoding == encoding {
+			ext = pe.ext
+		}
+	}
+
+	f, err := fsys.Open(name + ext)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if ctype == "" {
+		if ctype, err = sniffContentType(fsys, name); err != nil {
+			return false
+		}
+	}
+	header := w.Header()
+	header.Set("Content-Type", ctype)
+	header.Set("Content-Encoding", encoding)
+	if etag, ok := etags[name+ext]; ok {
+		header.Set("ETag", etag)
+	}
+	http.ServeContent(w, r, name, stat.ModTime(), rs)
+	return true
+}
+
+// negotiateEncoding 从 available 中选择 q 值最高的编码，q 值相同时按 available 的顺序
+// 编码本身的 q 值优先于 *，q=0 表示不接受，如 "br;q=0, *" 不会选择 br
+func negotiateEncoding(acceptEncoding string, available []string) string {
+	specs := parseAccept(acceptEncoding)
+	best, bestQ := "", 0.0
+	for _, encoding := range available {
+		q, wildcard := -1.0, 0.0
+		for _, spec := range specs {
+			if strings.EqualFold(spec.value, encoding) {
+				q = spec.q
+				break
+			}
+			if spec.value == "*" && wildcard == 0 {
+				wildcard = spec.q
+			}
+		}
+		if q < 0 {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// sniffContentType 根据原文件内容判断 Content-Type
+func sniffContentType(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var buf [sniffLen]byte
+	n, _ := io.ReadFull(f, buf[:])
+	return http.DetectContentType(buf[:n]), nil
+}
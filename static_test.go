@@ -0,0 +1,387 @@
+package xin_test
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fengjx/xin"
+)
+
+func TestStaticFSPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":        {Data: []byte("console.log('xin')")},
+		"app.js.br":     {Data: []byte("br-content")},
+		"app.js.gz":     {Data: []byte("gzip-content")},
+		"style.css":     {Data: []byte("body{}")},
+		"style.css.gz":  {Data: []byte("gzip-css")},
+		"plain.txt":     {Data: []byte("plain")},
+		"index.html":    {Data: []byte("<html>index</html>")},
+		"index.html.gz": {Data: []byte("gzip-index")},
+	}
+	mux := xin.NewMux()
+	mux.StaticFS("/assets/", fsys)
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		rangeHeader    string
+		code           int
+		body           string
+		encoding       string
+		contentType    string
+		vary           bool
+	}{
+		{"prefer br", "/assets/app.js", "gzip, deflate, br", "", http.StatusOK, "br-content", "br", "text/javascript; charset=utf-8", true},
+		{"q value", "/assets/app.js", "br;q=0.5, gzip", "", http.StatusOK, "gzip-content", "gzip", "text/javascript; charset=utf-8", true},
+		{"gzip fallback", "/assets/style.css", "br, gzip", "", http.StatusOK, "gzip-css", "gzip", "text/css; charset=utf-8", true},
+		{"wildcard", "/assets/style.css", "*", "", http.StatusOK, "gzip-css", "gzip", "text/css; charset=utf-8", true},
+		{"refused overrides wildcard", "/assets/app.js", "br;q=0, *", "", http.StatusOK, "gzip-content", "gzip", "text/javascript; charset=utf-8", true},
+		{"all refused", "/assets/app.js", "br;q=0, gzip;q=0, *", "", http.StatusOK, "console.log('xin')", "", "text/javascript; charset=utf-8", true},
+		{"not accepted", "/assets/app.js", "deflate", "", http.StatusOK, "console.log('xin')", "", "text/javascript; charset=utf-8", true},
+		{"no accept encoding", "/assets/app.js", "", "", http.StatusOK, "console.log('xin')", "", "text/javascript; charset=utf-8", true},
+		{"no sibling", "/assets/plain.txt", "br, gzip", "", http.StatusOK, "plain", "", "text/plain; charset=utf-8", false},
+		{"range", "/assets/app.js", "br", "bytes=0-1", http.StatusPartialContent, "br", "br", "text/javascript; charset=utf-8", true},
+		{"index", "/assets/", "gzip", "", http.StatusOK, "gzip-index", "gzip", "text/html; charset=utf-8", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			if tt.rangeHeader != "" {
+				r.Header.Set("Range", tt.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Fatalf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("expected Content-Encoding %q, got %q", tt.encoding, got)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.contentType, got)
+			}
+			if got := w.Header().Get("Vary") == "Accept-Encoding"; got != tt.vary {
+				t.Errorf("expected Vary Accept-Encoding %v, got %q", tt.vary, w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestStaticFSWith(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":           {Data: []byte("index")},
+		"app.3f2a1b9c.js":      {Data: []byte("app")},
+		"app.3f2a1b9c.js.gz":   {Data: []byte("gzip-app")},
+		"fonts/icon.woff2":     {Data: []byte("font")},
+		"docs/index.html":      {Data: []byte("docs")},
+		"images/logo.png":      {Data: []byte("png")},
+		"images/logo-dark.png": {Data: []byte("dark")},
+	}
+	mux := xin.NewMux()
+	mux.StaticFSWith("/assets/", fsys, xin.StaticOptions{
+		HashETag: true,
+		CacheControl: []xin.CacheControlRule{
+			{Pattern: "*.woff2", Value: "public, max-age=2592000"},
+			{Pattern: "images/*", Value: "public, max-age=3600"},
+		},
+	})
+
+	tests := []struct {
+		name         string
+		path         string
+		encoding     string
+		cacheControl string
+	}{
+		{"fingerprint", "/assets/app.3f2a1b9c.js", "", xin.ImmutableCacheControl},
+		{"fingerprint precompressed", "/assets/app.3f2a1b9c.js", "gzip", xin.ImmutableCacheControl},
+		{"index", "/assets/", "", "no-cache"},
+		{"directory index", "/assets/docs/", "", "no-cache"},
+		{"file name rule", "/assets/fonts/icon.woff2", "", "public, max-age=2592000"},
+		{"path rule", "/assets/images/logo-dark.png", "", "public, max-age=3600"},
+	}
+	etags := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Accept-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("expected Cache-Control %q, got %q", tt.cacheControl, got)
+			}
+			etag := w.Header().Get("ETag")
+			if len(etag) < 2 || etag[0] != '"' {
+				t.Fatalf("expected strong ETag, got %q", etag)
+			}
+			if etags[etag] {
+				t.Errorf("duplicate ETag %q", etag)
+			}
+			etags[etag] = true
+
+			r = httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.Header.Set("Accept-Encoding", tt.encoding)
+			r.Header.Set("If-None-Match", etag)
+			w = httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != http.StatusNotModified {
+				t.Errorf("expected status 304, got %d", w.Code)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/missing.1234abcd.js", nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d", w.Code)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "" {
+			t.Errorf("expected no Cache-Control, got %q", got)
+		}
+	})
+
+	t.Run("only files fs", func(t *testing.T) {
+		mux := xin.NewMux()
+		mux.StaticFSWith("/", xin.OnlyFilesFS(fsys, false, ""), xin.StaticOptions{HashETag: true})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		if w.Code != http.StatusOK || w.Body.String() != "docs" {
+			t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+		}
+		if w.Header().Get("ETag") == "" {
+			t.Error("expected ETag")
+		}
+	})
+}
+
+func TestCacheFS(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readFile := func(fsys fs.FS, name string) string {
+		t.Helper()
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	writeFile("a.svg", "aaaa")
+	writeFile("b.svg", "bbbb")
+	writeFile("c.svg", "cccc")
+	writeFile("large.bin", strings.Repeat("x", 64))
+
+	t.Run("hit", func(t *testing.T) {
+		cfs := xin.NewCacheFS(xin.Dir(dir, false), xin.CacheFSOptions{})
+		defer cfs.Close()
+		readFile(cfs, "a.svg")
+		if got := readFile(cfs, "a.svg"); got != "aaaa" {
+			t.Fatalf("expected aaaa, got %q", got)
+		}
+		stats := cfs.Stats()
+		if stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 || stats.Bytes != 4 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		cfs := xin.NewCacheFS(xin.Dir(dir, false), xin.CacheFSOptions{MaxEntries: 2, MaxBytes: 10, MaxFileSize: 32})
+		defer cfs.Close()
+		readFile(cfs, "a.svg")
+		readFile(cfs, "b.svg")
+		readFile(cfs, "a.svg")
+		readFile(cfs, "c.svg")
+		if got := readFile(cfs, "large.bin"); len(got) != 64 {
+			t.Fatalf("expected 64 bytes, got %d", len(got))
+		}
+		stats := cfs.Stats()
+		if stats.Entries != 2 || stats.Bytes != 8 || stats.Evictions != 1 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		// b.svg 最久未使用，已被淘汰
+		readFile(cfs, "a.svg")
+		readFile(cfs, "b.svg")
+		if stats := cfs.Stats(); stats.Hits != 2 || stats.Misses != 5 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("poll", func(t *testing.T) {
+		cfs := xin.NewCacheFS(xin.Dir(dir, false), xin.CacheFSOptions{PollInterval: 10 * time.Millisecond})
+		defer cfs.Close()
+		if got := readFile(cfs, "c.svg"); got != "cccc" {
+			t.Fatalf("expected cccc, got %q", got)
+		}
+		writeFile("c.svg", "changed")
+		deadline := time.Now().Add(time.Second)
+		for cfs.Stats().Entries != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("changed file was not invalidated")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := readFile(cfs, "c.svg"); got != "changed" {
+			t.Errorf("expected changed, got %q", got)
+		}
+	})
+
+	t.Run("static", func(t *testing.T) {
+		cfs := xin.NewCacheFS(xin.Dir(dir, false), xin.CacheFSOptions{})
+		defer cfs.Close()
+		mux := xin.NewMux()
+		mux.StaticFS("/icons/", cfs)
+		for range 2 {
+			r := httptest.NewRequest(http.MethodGet, "/icons/a.svg", nil)
+			r.Header.Set("Range", "bytes=1-2")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != http.StatusPartialContent || w.Body.String() != "aa" {
+				t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != "image/svg+xml" {
+				t.Errorf("expected Content-Type image/svg+xml, got %q", got)
+			}
+		}
+		if stats := cfs.Stats(); stats.Hits == 0 {
+			t.Errorf("expected cache hits, got %+v", stats)
+		}
+	})
+}
+
+func TestStaticListing(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"b.txt":         "bb",
+		"a.log":         "aaaa",
+		"c.bin":         "c",
+		".env":          "secret",
+		"sub/inner.txt": "inner",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mux := xin.NewMux()
+	mux.StaticFS("/files/", xin.Dir(dir, true))
+	mux.StaticFSWith("/all/", xin.Dir(dir, true), xin.StaticOptions{
+		Listing: xin.ListingOptions{
+			ShowHidden: true,
+			Template:   template.Must(template.New("custom").Parse(`{{range .Items}}{{.Name}} {{end}}`)),
+		},
+	})
+	mux.StaticFS("/only/", xin.Dir(dir, false))
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	names := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var listing xin.DirListing
+		if err := json.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, item := range listing.Items {
+			names = append(names, item.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	t.Run("html", func(t *testing.T) {
+		w := get("/files/", "text/html,application/xhtml+xml,*/*;q=0.8")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+			t.Errorf("unexpected Content-Type %q", got)
+		}
+		body := w.Body.String()
+		for _, s := range []string{`href="sub/"`, `href="a.log"`, "4 B", "?sort=size&amp;order=asc"} {
+			if !strings.Contains(body, s) {
+				t.Errorf("expected body to contain %q", s)
+			}
+		}
+		if strings.Contains(body, ".env") {
+			t.Error("hidden file should not be listed")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		w := get("/files/", "application/json")
+		if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+			t.Fatalf("unexpected Content-Type %q", got)
+		}
+		if got := names(t, w); got != "sub,a.log,b.txt,c.bin" {
+			t.Errorf("unexpected items %s", got)
+		}
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		tests := []struct {
+			query string
+			want  string
+		}{
+			{"?sort=name&order=desc", "sub,c.bin,b.txt,a.log"},
+			{"?sort=size", "sub,c.bin,b.txt,a.log"},
+			{"?sort=size&order=desc", "sub,a.log,b.txt,c.bin"},
+			{"?sort=unknown", "sub,a.log,b.txt,c.bin"},
+		}
+		for _, tt := range tests {
+			if got := names(t, get("/files/"+tt.query, "application/json")); got != tt.want {
+				t.Errorf("%s: expected %s, got %s", tt.query, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("sub directory", func(t *testing.T) {
+		w := get("/files/sub/", "application/json")
+		if got := names(t, w); got != "inner.txt" {
+			t.Errorf("unexpected items %s", got)
+		}
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		w := get("/all/", "")
+		if got := w.Body.String(); got != "sub .env a.log b.txt c.bin " {
+			t.Errorf("unexpected body %q", got)
+		}
+	})
+
+	t.Run("only files", func(t *testing.T) {
+		if w := get("/only/", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package xin
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultWritableMaxSize 上传文件默认的最大字节数
+const DefaultWritableMaxSize = 100 << 20
+
+const writableAllow = "GET, HEAD, PUT, DELETE, MKCOL, OPTIONS"
+
+// WritableOptions 可写静态文件服务配置
+type WritableOptions struct {
+	// Authorize 校验写请求（PUT、DELETE、MKCOL），返回 false 时响应 403
+	// 为 nil 时拒绝所有写请求，读请求不经过校验，需要时可以配合 middleware.BasicAuth 使用
+	Authorize func(r *http.Request) bool
+	// MaxSize 上传文件的最大字节数，超出时响应 413，默认 DefaultWritableMaxSize
+	MaxSize int64
+	// CreateDirs PUT 时自动创建不存在的上级目录，默认上级目录不存在时响应 409
+	CreateDirs bool
+	// NoOverwrite 禁止覆盖已存在的文件，已存在时 PUT 响应 412
+	NoOverwrite bool
+	// FileMode 上传文件的权限，默认 0644
+	FileMode fs.FileMode
+	// DirMode 创建目录的权限，默认 0755
+	DirMode fs.FileMode
+	// Static GET、HEAD 请求的静态文件服务配置
+	Static StaticOptions
+	// ListDirectory GET 请求目录时返回目录列表
+	ListDirectory bool
+}
+
+// StaticWritable 注册可写的静态文件服务，参考 WritableHandler
+//
+//	mux.StaticWritable("/artifacts/", "./artifacts", xin.WritableOptions{
+//		Authorize: func(r *http.Request) bool {
+//			return r.Header.Get("Authorization") == "Bearer "+token
+//		},
+//		CreateDirs: true,
+//	})
+func (mux *Mux) StaticWritable(pattern string, root string, opts WritableOptions) *Mux {
+	_, prefix := splitPattern(pattern)
+	mux.ServeMux.Handle(pattern, WritableHandler(prefix, root, opts))
+	return mux
+}
+
+// WritableHandler 返回可写的静态文件服务
+// GET、HEAD 返回文件；PUT 上传文件，先写入临时文件再重命名，新建时响应 201，覆盖时响应 204；
+// DELETE 删除文件或目录；MKCOL 创建目录
+// 请求路径不能包含 ..，也不能通过符号链接访问 root 以外的文件
+func WritableHandler(prefix string, root string, opts WritableOptions) http.Handler {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultWritableMaxSize
+	}
+	if opts.FileMode == 0 {
+		opts.FileMode = 0o644
+	}
+	if opts.DirMode == 0 {
+		opts.DirMode = 0o755
+	}
+	h := &writableHandler{
+		root:  root,
+		opts:  opts,
+		files: FileHandlerWith("", Dir(root, opts.ListDirectory), opts.Static),
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upath, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h.serve(w, r, upath)
+	})
+}
+
+type writableHandler struct {
+	root  string
+	opts  WritableOptions
+	files http.Handler
+}
+
+func (h *writableHandler) serve(w http.ResponseWriter, r *http.Request, upath string) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = upath
+		r2.URL.RawPath = ""
+		h.files.ServeHTTP(w, r2)
+		return
+	case http.MethodOptions:
+		w.Header().Set("Allow", writableAllow)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPut, http.MethodDelete, "MKCOL":
+	default:
+		w.Header().Set("Allow", writableAllow)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if h.opts.Authorize == nil || !h.opts.Authorize(r) {
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+	name, err := h.resolve(upath)
+	if err != nil {
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if strings.HasSuffix(upath, "/") {
+			http.Error(w, "cannot PUT a directory", http.StatusConflict)
+			return
+		}
+		h.put(w, r, name)
+	case http.MethodDelete:
+		h.delete(w, name)
+	default:
+		h.mkcol(w, r, name)
+	}
+}
+
+// resolve 返回请求路径在 root 下的文件路径，root 本身和 root 以外的路径返回错误
+func (h *writableHandler) resolve(upath string) (string, error) {
+	if strings.Contains(upath, "\x00") || strings.Contains(upath, "\\") {
+		return "", fs.ErrInvalid
+	}
+	for _, seg := range strings.Split(upath, "/") {
+		if seg == ".." {
+			return "", fs.ErrInvalid
+		}
+	}
+	rel := strings.TrimPrefix(path.Clean("/"+upath), "/")
+	if rel == "" {
+		return "", fs.ErrInvalid
+	}
+	root, err := filepath.EvalSymlinks(h.root)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Join(root, filepath.FromSlash(rel))
+	// 已存在的上级目录不能是指向 root 以外的符号链接
+	dir := filepath.Dir(name)
+	for {
+		real, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			if real != root && !strings.HasPrefix(real, root+string(filepath.Separator)) {
+				return "", fs.ErrPermission
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) || dir == root {
+			return "", err
+		}
+		dir = filepath.Dir(dir)
+	}
+	// 目标是符号链接时不跟随，PUT 会替换链接本身，DELETE 只删除链接
+	return name, nil
+}
+
+func (h *writableHandler) put(w http.ResponseWriter, r *http.Request, name string) {
+	if r.ContentLength > h.opts.MaxSize {
+		http.Error(w, "413 Request Entity Too Large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	stat, err := os.Lstat(name)
+	exists := err == nil
+	switch {
+	case exists && stat.IsDir():
+		http.Error(w, "409 Conflict", http.StatusConflict)
+		return
+	case exists && h.opts.NoOverwrite:
+		http.Error(w, "412 Precondition Failed", http.StatusPreconditionFailed)
+		return
+	}
+	dir := filepath.Dir(name)
+	if h.opts.CreateDirs {
+		err = os.MkdirAll(dir, h.opts.DirMode)
+	} else {
+		_, err = os.Stat(dir)
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, "409 Conflict", http.StatusConflict)
+			return
+		}
+		writeFSError(w, err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, http.MaxBytesReader(w, r.Body, h.opts.MaxSize))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "413 Request Entity Too Large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if err = os.Chmod(tmp.Name(), h.opts.FileMode); err != nil {
+		writeFSError(w, err)
+		return
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		writeFSError(w, err)
+		return
+	}
+	if exists {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *writableHandler) delete(w http.ResponseWriter, name string) {
+	stat, err := os.Lstat(name)
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	if stat.IsDir() {
+		err = os.RemoveAll(name)
+	} else {
+		err = os.Remove(name)
+	}
+	if err != nil {
+		writeFSError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *writableHandler) mkcol(w http.ResponseWriter, r *http.Request, name string) {
+	// RFC 4918 MKCOL 不支持请求体
+	if r.ContentLength > 0 {
+		http.Error(w, "415 Unsupported Media Type", http.StatusUnsupportedMediaType)
+		return
+	}
+	if _, err := os.Lstat(name); err == nil {
+		w.Header().Set("Allow", writableAllow)
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := os.Mkdir(name, h.opts.DirMode); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, "409 Conflict", http.StatusConflict)
+			return
+		}
+		writeFSError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
@@ -0,0 +1,99 @@
+package xin_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fengjx/xin"
+)
+
+func TestStaticWritable(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	authorize := func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer token"
+	}
+	h := xin.WritableHandler("/artifacts/", root, xin.WritableOptions{
+		Authorize:  authorize,
+		MaxSize:    16,
+		CreateDirs: true,
+	})
+	strict := xin.WritableHandler("/strict/", root, xin.WritableOptions{
+		Authorize:   authorize,
+		NoOverwrite: true,
+	})
+	readOnly := xin.WritableHandler("/ro/", root, xin.WritableOptions{})
+
+	do := func(h http.Handler, method, target string, body io.Reader) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, body)
+		r.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		h      http.Handler
+		method string
+		target string
+		body   io.Reader
+		code   int
+	}{
+		{"put create", h, http.MethodPut, "/artifacts/build/1/app.tar", strings.NewReader("v1"), http.StatusCreated},
+		{"put replace", h, http.MethodPut, "/artifacts/build/1/app.tar", strings.NewReader("v2"), http.StatusNoContent},
+		{"put too large", h, http.MethodPut, "/artifacts/large.bin", strings.NewReader(strings.Repeat("x", 17)), http.StatusRequestEntityTooLarge},
+		{"put too large chunked", h, http.MethodPut, "/artifacts/large.bin", io.MultiReader(strings.NewReader(strings.Repeat("x", 17))), http.StatusRequestEntityTooLarge},
+		{"put directory", h, http.MethodPut, "/artifacts/build", strings.NewReader("x"), http.StatusConflict},
+		{"put traversal", h, http.MethodPut, "/artifacts/../evil.txt", strings.NewReader("x"), http.StatusForbidden},
+		{"put encoded traversal", h, http.MethodPut, "/artifacts/%2e%2e/evil.txt", strings.NewReader("x"), http.StatusForbidden},
+		{"put symlink escape", h, http.MethodPut, "/artifacts/escape/evil.txt", strings.NewReader("x"), http.StatusForbidden},
+		{"put missing parent", strict, http.MethodPut, "/strict/missing/app.tar", strings.NewReader("x"), http.StatusConflict},
+		{"put no overwrite", strict, http.MethodPut, "/strict/build/1/app.tar", strings.NewReader("x"), http.StatusPreconditionFailed},
+		{"put unauthorized", readOnly, http.MethodPut, "/ro/app.tar", strings.NewReader("x"), http.StatusForbidden},
+		{"get", readOnly, http.MethodGet, "/ro/build/1/app.tar", nil, http.StatusOK},
+		{"mkcol", h, "MKCOL", "/artifacts/releases", nil, http.StatusCreated},
+		{"mkcol exists", h, "MKCOL", "/artifacts/releases", nil, http.StatusMethodNotAllowed},
+		{"mkcol missing parent", h, "MKCOL", "/artifacts/a/b", nil, http.StatusConflict},
+		{"mkcol body", h, "MKCOL", "/artifacts/body", strings.NewReader("x"), http.StatusUnsupportedMediaType},
+		{"delete file", h, http.MethodDelete, "/artifacts/build/1/app.tar", nil, http.StatusNoContent},
+		{"delete missing", h, http.MethodDelete, "/artifacts/build/1/app.tar", nil, http.StatusNotFound},
+		{"delete directory", h, http.MethodDelete, "/artifacts/build", nil, http.StatusNoContent},
+		{"delete root", h, http.MethodDelete, "/artifacts/", nil, http.StatusForbidden},
+		{"method not allowed", h, http.MethodPost, "/artifacts/x", nil, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.h, tt.method, tt.target, tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.name == "get" && w.Body.String() != "v2" {
+				t.Errorf("expected body v2, got %q", w.Body.String())
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "build")); !os.IsNotExist(err) {
+		t.Errorf("expected build directory to be deleted, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "releases")); err != nil {
+		t.Errorf("expected releases directory, got %v", err)
+	}
+	entries, err := os.ReadDir(outside)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected nothing written outside root, got %v %v", entries, err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(root, ".upload-*"))
+	if len(matches) != 0 {
+		t.Errorf("expected temp files to be removed, got %v", matches)
+	}
+}
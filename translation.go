@@ -0,0 +1,184 @@
+package xin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	zhtranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+const (
+	// LocaleEN 英文
+	LocaleEN = "en"
+	// LocaleZH 中文
+	LocaleZH = "zh"
+)
+
+var (
+	uni           *ut.UniversalTranslator
+	defaultLocale = LocaleEN
+	// translations RegisterTranslation 注册的错误信息，SetValidate 替换实例后重新注册
+	translations []translation
+)
+
+// translation 自定义的校验错误信息
+type translation struct {
+	tag    string
+	locale string
+	text   string
+}
+
+// FieldErrors 校验失败的字段和对应的错误信息，key 为 json 字段名
+// 嵌套结构体的字段使用 "." 连接，如 "address.city"
+type FieldErrors map[string]string
+
+// Error 实现 error 接口，按字段名排序后输出
+func (fe FieldErrors) Error() string {
+	keys := make([]string, 0, len(fe))
+	for k := range fe {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(fe[k])
+	}
+	return sb.String()
+}
+
+// initTranslator 注册英文和中文的校验错误翻译，以及 RegisterTranslation 注册的错误信息
+func initTranslator(v *validator.Validate) {
+	enLocale := en.New()
+	uni = ut.New(enLocale, enLocale, zh.New())
+	enTrans, _ := uni.GetTranslator(LocaleEN)
+	zhTrans, _ := uni.GetTranslator(LocaleZH)
+	if err := entranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		panic(err)
+	}
+	if err := zhtranslations.RegisterDefaultTranslations(v, zhTrans); err != nil {
+		panic(err)
+	}
+	for _, t := range translations {
+		if err := registerTranslation(v, t); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// SetDefaultLocale 设置默认语言，Accept-Language 中没有支持的语言时使用
+func SetDefaultLocale(locale string) {
+	defaultLocale = locale
+}
+
+// GetTranslator 获取指定语言的翻译器，不支持的语言返回默认语言的翻译器
+func GetTranslator(locale string) ut.Translator {
+	if trans, ok := uni.GetTranslator(normalizeLocale(locale)); ok {
+		return trans
+	}
+	trans, _ := uni.GetTranslator(defaultLocale)
+	return trans
+}
+
+// RequestTranslator 根据请求头 Accept-Language 选择翻译器
+func RequestTranslator(r *http.Request) ut.Translator {
+	for _, spec := range parseAccept(r.Header.Get("Accept-Language")) {
+		if spec.q <= 0 {
+			break
+		}
+		if trans, ok := uni.GetTranslator(normalizeLocale(spec.value)); ok {
+			return trans
+		}
+	}
+	return GetTranslator(defaultLocale)
+}
+
+// TranslateError 将校验错误翻译为字段和错误信息的映射，语言根据 Accept-Language 选择
+// err 不是校验错误时返回 nil
+func TranslateError(r *http.Request, err error) FieldErrors {
+	return translateError(RequestTranslator(r), err)
+}
+
+// TranslateErrorLocale 使用指定语言翻译校验错误
+// err 不是校验错误时返回 nil
+func TranslateErrorLocale(locale string, err error) FieldErrors {
+	return translateError(GetTranslator(locale), err)
+}
+
+func translateError(trans ut.Translator, err error) FieldErrors {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	fe := make(FieldErrors, len(verrs))
+	for _, e := range verrs {
+		fe[fieldKey(e)] = e.Translate(trans)
+	}
+	return fe
+}
+
+// fieldKey 去掉命名空间中最外层的结构体名
+func fieldKey(e validator.FieldError) string {
+	ns := e.Namespace()
+	if _, key, ok := strings.Cut(ns, "."); ok {
+		return key
+	}
+	return e.Field()
+}
+
+// normalizeLocale 将 "zh-CN"、"en_US" 等转换为支持的语言名
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// jsonFieldName 校验错误中使用 json 标签作为字段名
+func jsonFieldName(sf reflect.StructField) string {
+	name := tagName(sf, "json")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// RegisterTranslation 为校验标签注册指定语言的错误信息
+// text 中可以使用 {0} 表示字段名，{1} 表示标签参数，如 "{0}必须是有效的手机号"
+// 之后调用 SetValidate 替换实例时会重新注册
+func RegisterTranslation(tag, locale, text string) error {
+	t := translation{tag: tag, locale: locale, text: text}
+	if err := registerTranslation(validate, t); err != nil {
+		return err
+	}
+	translations = append(translations, t)
+	return nil
+}
+
+func registerTranslation(v *validator.Validate, t translation) error {
+	trans, ok := uni.GetTranslator(normalizeLocale(t.locale))
+	if !ok {
+		return fmt.Errorf("xin: unsupported locale %s", t.locale)
+	}
+	return v.RegisterTranslation(t.tag, trans, func(ut ut.Translator) error {
+		return ut.Add(t.tag, t.text, true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		msg, err := ut.T(fe.Tag(), fe.Field(), fe.Param())
+		if err != nil {
+			return fe.Error()
+		}
+		return msg
+	})
+}
@@ -0,0 +1,103 @@
+package xin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/fengjx/xin"
+)
+
+func TestTranslateError(t *testing.T) {
+	type address struct {
+		City string `json:"city" binding:"required"`
+	}
+	type req struct {
+		UserName string  `json:"user_name" binding:"required"`
+		Age      int     `json:"age" binding:"gte=18"`
+		Address  address `json:"address"`
+	}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       map[string]string
+	}{
+		{
+			name:           "english",
+			acceptLanguage: "en-US,en;q=0.9",
+			expected: map[string]string{
+				"user_name":    "user_name is a required field",
+				"age":          "age must be 18 or greater",
+				"address.city": "city is a required field",
+			},
+		},
+		{
+			name:           "chinese by q value",
+			acceptLanguage: "fr;q=0.9,zh-CN;q=0.8,en;q=0.1",
+			expected: map[string]string{
+				"user_name":    "user_name为必填字段",
+				"age":          "age必须大于或等于18",
+				"address.city": "city为必填字段",
+			},
+		},
+		{
+			name:           "fallback to default locale",
+			acceptLanguage: "fr",
+			expected: map[string]string{
+				"user_name": "user_name is a required field",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age":1}`))
+			r.Header.Set("Accept-Language", tt.acceptLanguage)
+			err := xin.ShouldBindJSON(r, &req{})
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			fe := xin.TranslateError(r, err)
+			for field, msg := range tt.expected {
+				if fe[field] != msg {
+					t.Errorf("field %s: expected %q, got %q", field, msg, fe[field])
+				}
+			}
+		})
+	}
+
+	if fe := xin.TranslateErrorLocale(xin.LocaleZH, http.ErrNoCookie); fe != nil {
+		t.Errorf("expected nil for non validation error, got %v", fe)
+	}
+}
+
+func TestRegisterTranslationAfterSetValidate(t *testing.T) {
+	old := xin.GetValidate()
+	t.Cleanup(func() {
+		xin.SetValidate(old)
+	})
+
+	if err := xin.RegisterTranslation("even", xin.LocaleZH, "{0}必须是偶数"); err != nil {
+		t.Fatal(err)
+	}
+	// 替换实例后之前注册的错误信息仍然有效
+	v := xin.NewValidate()
+	if err := v.RegisterValidation("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	}); err != nil {
+		t.Fatal(err)
+	}
+	xin.SetValidate(v)
+
+	r := httptest.NewRequest(http.MethodGet, "/?count=3", nil)
+	err := xin.ShouldBind(r, &struct {
+		Count int `json:"count" binding:"even"`
+	}{})
+	if fe := xin.TranslateErrorLocale(xin.LocaleZH, err); fe["count"] != "count必须是偶数" {
+		t.Errorf("unexpected message: %v", fe)
+	}
+}
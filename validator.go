@@ -0,0 +1,88 @@
+package xin
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// StructValidator 参数校验引擎接口，实现该接口可以替换默认的 go-playground/validator
+type StructValidator interface {
+	// ValidateStruct 校验结构体，校验失败返回错误
+	ValidateStruct(obj any) error
+	// Engine 返回底层的校验引擎
+	Engine() any
+}
+
+var (
+	validate        *validator.Validate
+	structValidator StructValidator
+)
+
+func init() {
+	SetValidate(NewValidate())
+}
+
+// defaultValidator 基于 go-playground/validator 的校验实现
+type defaultValidator struct {
+	validate *validator.Validate
+}
+
+func (v *defaultValidator) ValidateStruct(obj any) error {
+	return v.validate.Struct(obj)
+}
+
+func (v *defaultValidator) Engine() any {
+	return v.validate
+}
+
+// NewValidate 创建与默认配置一致的 validator 实例
+// 校验标签为 binding，错误信息中的字段名使用 json 标签
+func NewValidate() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(jsonFieldName)
+	return v
+}
+
+// SetValidate 使用自定义的 validator 实例替换默认实例
+// 会为该实例注册中英文的错误翻译，参考 TranslateError
+func SetValidate(v *validator.Validate) {
+	initTranslator(v)
+	validate = v
+	structValidator = &defaultValidator{validate: v}
+}
+
+// GetValidate 获取当前使用的 validator 实例
+func GetValidate() *validator.Validate {
+	return validate
+}
+
+// SetValidator 使用其他校验引擎替换默认的 go-playground/validator
+// 替换后 RegisterValidation 等注册函数和 TranslateError 不再对绑定结果生效
+func SetValidator(v StructValidator) {
+	structValidator = v
+}
+
+// ValidateStruct 使用当前的校验引擎校验结构体
+func ValidateStruct(obj any) error {
+	return structValidator.ValidateStruct(obj)
+}
+
+// RegisterValidation 注册自定义校验标签，如 mobile、idcard
+//
+//	xin.RegisterValidation("mobile", func(fl validator.FieldLevel) bool {
+//		return mobileRegexp.MatchString(fl.Field().String())
+//	})
+func RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	return validate.RegisterValidation(tag, fn, callValidationEvenIfNull...)
+}
+
+// RegisterStructValidation 注册结构体级别的校验函数，用于跨字段校验
+// types 为需要校验的结构体类型的零值，如 RegisterStructValidation(fn, SignupReq{})
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterAlias 注册校验标签别名，如 RegisterAlias("slug", "lowercase,alphanum")
+func RegisterAlias(alias, tags string) {
+	validate.RegisterAlias(alias, tags)
+}
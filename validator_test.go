@@ -0,0 +1,97 @@
+package xin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/fengjx/xin"
+)
+
+var mobileRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+type signupReq struct {
+	Mobile          string `json:"mobile" binding:"required,mobile"`
+	Slug            string `json:"slug" binding:"slug"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+func TestRegisterValidation(t *testing.T) {
+	// 在独立的实例上注册，避免影响其他测试
+	old := xin.GetValidate()
+	xin.SetValidate(xin.NewValidate())
+	t.Cleanup(func() {
+		xin.SetValidate(old)
+	})
+
+	err := xin.RegisterValidation("mobile", func(fl validator.FieldLevel) bool {
+		return mobileRegexp.MatchString(fl.Field().String())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xin.RegisterAlias("slug", "lowercase,alphanum")
+	xin.RegisterStructValidation(func(sl validator.StructLevel) {
+		req := sl.Current().Interface().(signupReq)
+		if req.Password != req.ConfirmPassword {
+			sl.ReportError(req.ConfirmPassword, "confirm_password", "ConfirmPassword", "eqfield", "password")
+		}
+	}, signupReq{})
+	if err = xin.RegisterTranslation("mobile", xin.LocaleZH, "{0}必须是有效的手机号"); err != nil {
+		t.Fatal(err)
+	}
+
+	bind := func(body string) error {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		return xin.ShouldBindJSON(r, &signupReq{})
+	}
+
+	if err = bind(`{"mobile":"13800138000","slug":"abc","password":"p","confirm_password":"p"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = bind(`{"mobile":"123","slug":"ABC","password":"p","confirm_password":"q"}`)
+	fe := xin.TranslateErrorLocale(xin.LocaleZH, err)
+	if fe["mobile"] != "mobile必须是有效的手机号" {
+		t.Errorf("unexpected mobile message: %q", fe["mobile"])
+	}
+	if _, ok := fe["slug"]; !ok {
+		t.Errorf("expected slug error, got %v", fe)
+	}
+	if _, ok := fe["confirm_password"]; !ok {
+		t.Errorf("expected confirm_password error, got %v", fe)
+	}
+}
+
+type rejectValidator struct{}
+
+var errRejected = errors.New("rejected")
+
+func (rejectValidator) ValidateStruct(obj any) error {
+	return errRejected
+}
+
+func (rejectValidator) Engine() any {
+	return nil
+}
+
+func TestSetValidator(t *testing.T) {
+	v := xin.GetValidate()
+	t.Cleanup(func() {
+		xin.SetValidate(v)
+	})
+
+	xin.SetValidator(rejectValidator{})
+	r := httptest.NewRequest(http.MethodGet, "/?name=foo", nil)
+	if err := xin.ShouldBind(r, &struct {
+		Name string `json:"name"`
+	}{}); !errors.Is(err, errRejected) {
+		t.Errorf("expected custom validator error, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+)
+
+// deflate 块结束标记，RFC 7692 7.2.1 要求发送时去掉，接收时补上
+const deflateTail = "\x00\x00\xff\xff"
+
+var (
+	flateWriterPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	flateReaderPool  = sync.Pool{
+		New: func() any {
+			return flate.NewReader(nil)
+		},
+	}
+	compressBufferPool = sync.Pool{
+		New: func() any {
+			return new(bytes.Buffer)
+		},
+	}
+)
+
+// compress 压缩单条消息，不保留上下文，返回的 buffer 使用后需要调用 putCompressBuffer
+func compress(data []byte, level int) (*bytes.Buffer, error) {
+	buf := compressBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	pool := &flateWriterPools[level-flate.HuffmanOnly]
+	fw, _ := pool.Get().(*flate.Writer)
+	if fw == nil {
+		var err error
+		if fw, err = flate.NewWriter(buf, level); err != nil {
+			return nil, err
+		}
+	} else {
+		fw.Reset(buf)
+	}
+	defer pool.Put(fw)
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	buf.Truncate(buf.Len() - len(deflateTail))
+	return buf, nil
+}
+
+func putCompressBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	compressBufferPool.Put(buf)
+}
+
+// decompress 解压单条消息，解压后超过 limit 时返回 ErrMessageTooBig，limit 小于等于 0 表示不限制
+func decompress(data []byte, limit int64) ([]byte, error) {
+	// 补上块结束标记和一个空的最终块，让 reader 正常返回 io.EOF
+	src := io.MultiReader(bytes.NewReader(data), strings.NewReader(deflateTail+"\x01\x00\x00\xff\xff"))
+	fr := flateReaderPool.Get().(io.ReadCloser)
+	defer flateReaderPool.Put(fr)
+	if err := fr.(flate.Resetter).Reset(src, nil); err != nil {
+		return nil, err
+	}
+	var r io.Reader = fr
+	if limit > 0 {
+		r = io.LimitReader(fr, limit+1)
+	}
+	p, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(p)) > limit {
+		return nil, ErrMessageTooBig
+	}
+	return p, nil
+}
+
+// parseDeflateOffer 判断客户端的 Sec-WebSocket-Extensions 中是否有可以接受的 permessage-deflate 提议
+// 服务端不保留压缩上下文，不支持限制服务端窗口大小的提议
+func parseDeflateOffer(header []string) bool {
+	for _, value := range header {
+		for _, ext := range strings.Split(value, ",") {
+			params := strings.Split(ext, ";")
+			if strings.TrimSpace(params[0]) != "permessage-deflate" {
+				continue
+			}
+			ok := true
+			for _, param := range params[1:] {
+				name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				switch strings.TrimSpace(name) {
+				case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+				case "server_max_window_bits":
+					// flate 固定使用 32KB 窗口
+					ok = strings.Trim(strings.TrimSpace(val), `"`) == "15"
+				default:
+					ok = false
+				}
+				if !ok {
+					break
+				}
+			}
+			if ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,458 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fengjx/go-halo/json"
+)
+
+const (
+	finalBit = 1 << 7
+	rsv1Bit  = 1 << 6
+	rsv2Bit  = 1 << 5
+	rsv3Bit  = 1 << 4
+	maskBit  = 1 << 7
+
+	continuationFrame = 0
+
+	maxControlPayload = 125
+	readChunkSize     = 32 << 10
+	closeTimeout      = time.Second
+)
+
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode int
+	length int64
+	masked bool
+	mask   [4]byte
+}
+
+// Conn WebSocket 连接
+// 同一时间只能有一个 goroutine 调用 ReadMessage，写入方法可以并发调用
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	isClient bool
+
+	subprotocol    string
+	compress       bool
+	compressLevel  int
+	maxMessageSize int64
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+
+	readErr error
+
+	writeMtx  sync.Mutex
+	closeSent bool
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isClient bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	return &Conn{
+		conn:           conn,
+		br:             br,
+		isClient:       isClient,
+		maxMessageSize: DefaultMaxMessageSize,
+		closed:         make(chan struct{}),
+	}
+}
+
+// Subprotocol 返回协商的子协议
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr 返回对端地址
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// LocalAddr 返回本地地址
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// NetConn 返回底层连接
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// Done 连接关闭后关闭
+func (c *Conn) Done() <-chan struct{} {
+	return c.closed
+}
+
+// ReadMessage 读取一条完整的消息，自动回复 ping 和关闭帧
+// 对端关闭连接时返回 *CloseError，违反协议时返回 ErrProtocol，消息过大时返回 ErrMessageTooBig
+// 返回错误后连接不可再读取
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	messageType, p, err = c.readMessage()
+	if err != nil {
+		c.readErr = err
+	}
+	return messageType, p, err
+}
+
+// ReadJSON 读取一条消息并解析为 json
+func (c *Conn) ReadJSON(v any) error {
+	_, p, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.FromBytes(p, v)
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	var (
+		messageType int
+		compressed  bool
+		payload     []byte
+	)
+	for {
+		if c.readTimeout > 0 {
+			_ = c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		h, err := c.readFrameHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+		if h.masked == c.isClient {
+			return 0, nil, c.fail(CloseProtocolError, "bad mask")
+		}
+		if h.opcode >= CloseMessage {
+			if err = c.handleControl(h); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		switch h.opcode {
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+			if h.rsv1 {
+				return 0, nil, c.fail(CloseProtocolError, "rsv1 set on continuation frame")
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			if h.rsv1 && !c.compress {
+				return 0, nil, c.fail(CloseProtocolError, "rsv1 set without compression")
+			}
+			messageType = h.opcode
+			compressed = h.rsv1
+		default:
+			return 0, nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", h.opcode))
+		}
+
+		if c.maxMessageSize > 0 && int64(len(payload))+h.length > c.maxMessageSize {
+			_ = c.closeWith(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+		if payload, err = c.readPayload(payload, h); err != nil {
+			return 0, nil, err
+		}
+		if !h.fin {
+			continue
+		}
+
+		if compressed {
+			if payload, err = decompress(payload, c.maxMessageSize); err != nil {
+				if errors.Is(err, ErrMessageTooBig) {
+					_ = c.closeWith(CloseMessageTooBig, "")
+					return 0, nil, err
+				}
+				return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid compressed data")
+			}
+		}
+		if messageType == TextMessage && !utf8.Valid(payload) {
+			return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid utf-8")
+		}
+		return messageType, payload, nil
+	}
+}
+
+func (c *Conn) readFrameHeader() (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+		return h, err
+	}
+	if b[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return h, c.fail(CloseProtocolError, "rsv2 or rsv3 set")
+	}
+	h.fin = b[0]&finalBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = int(b[0] & 0x0f)
+	h.masked = b[1]&maskBit != 0
+	h.length = int64(b[1] & 0x7f)
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, b[:8]); err != nil {
+			return h, err
+		}
+		n := binary.BigEndian.Uint64(b[:8])
+		if n>>63 != 0 {
+			return h, c.fail(CloseProtocolError, "invalid payload length")
+		}
+		h.length = int64(n)
+	}
+	if h.masked {
+		if _, err := io.ReadFull(c.br, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+	return h, nil
+}
+
+func (c *Conn) readPayload(dst []byte, h frameHeader) ([]byte, error) {
+	start := len(dst)
+	// 分段读取，避免对端声明超大长度时一次性分配内存
+	for remaining := h.length; remaining > 0; {
+		n := int(min(remaining, readChunkSize))
+		off := len(dst)
+		dst = append(dst, make([]byte, n)...)
+		if _, err := io.ReadFull(c.br, dst[off:]); err != nil {
+			return nil, err
+		}
+		remaining -= int64(n)
+	}
+	if h.masked {
+		maskBytes(h.mask, dst[start:])
+	}
+	return dst, nil
+}
+
+func (c *Conn) handleControl(h frameHeader) error {
+	if !h.fin || h.length > maxControlPayload {
+		return c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if h.rsv1 {
+		return c.fail(CloseProtocolError, "rsv1 set on control frame")
+	}
+	payload, err := c.readPayload(nil, h)
+	if err != nil {
+		return err
+	}
+	switch h.opcode {
+	case PingMessage:
+		if err = c.writeControl(PongMessage, payload); err != nil && !errors.Is(err, ErrCloseSent) {
+			return err
+		}
+		return nil
+	case PongMessage:
+		// 收到任意帧都会延长读超时，不需要额外处理
+		return nil
+	case CloseMessage:
+		return c.handleClose(payload)
+	default:
+		return c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", h.opcode))
+	}
+}
+
+func (c *Conn) handleClose(payload []byte) error {
+	ce := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		ce.Code = int(binary.BigEndian.Uint16(payload))
+		ce.Text = string(payload[2:])
+		if !validCloseCode(ce.Code) {
+			return c.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(ce.Text) {
+			return c.fail(CloseInvalidFramePayloadData, "invalid utf-8 close reason")
+		}
+	}
+	// 回复关闭帧后关闭底层连接
+	code := ce.Code
+	if code == CloseNoStatusReceived {
+		code = 0
+	}
+	_ = c.closeWith(code, "")
+	return ce
+}
+
+// fail 发送关闭帧并关闭连接，返回 ErrProtocol
+func (c *Conn) fail(code int, text string) error {
+	_ = c.closeWith(code, text)
+	return fmt.Errorf("%w: %s", ErrProtocol, text)
+}
+
+// WriteMessage 写入一条消息，协商了压缩时文本和二进制消息会被压缩
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case PingMessage, PongMessage:
+		return c.writeControl(messageType, data)
+	case CloseMessage:
+		return errors.New("websocket: use CloseWithCode to send close message")
+	default:
+		return fmt.Errorf("websocket: unknown message type %d", messageType)
+	}
+	rsv1 := false
+	if c.compress && len(data) > 0 {
+		buf, err := compress(data, c.compressLevel)
+		if err != nil {
+			return err
+		}
+		defer putCompressBuffer(buf)
+		data = buf.Bytes()
+		rsv1 = true
+	}
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	return c.writeFrame(messageType, rsv1, data)
+}
+
+// WriteJSON 将 v 编码为 json 后作为文本消息写入
+func (c *Conn) WriteJSON(v any) error {
+	data, err := json.ToBytes(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// WritePing 发送 ping
+func (c *Conn) WritePing(data []byte) error {
+	return c.writeControl(PingMessage, data)
+}
+
+func (c *Conn) writeControl(opcode int, data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: control frame payload too big")
+	}
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	return c.writeFrame(opcode, false, data)
+}
+
+// writeFrame 写入单个帧，调用方需要持有 writeMtx
+func (c *Conn) writeFrame(opcode int, rsv1 bool, payload []byte) error {
+	hdr := make([]byte, 2, 14)
+	hdr[0] = finalBit | byte(opcode)
+	if rsv1 {
+		hdr[0] |= rsv1Bit
+	}
+	n := len(payload)
+	switch {
+	case n <= 125:
+		hdr[1] = byte(n)
+	case n <= 0xffff:
+		hdr[1] = 126
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(n))
+	default:
+		hdr[1] = 127
+		hdr = binary.BigEndian.AppendUint64(hdr, uint64(n))
+	}
+	if c.isClient {
+		hdr[1] |= maskBit
+		var key [4]byte
+		binary.LittleEndian.PutUint32(key[:], rand.Uint32())
+		hdr = append(hdr, key[:]...)
+		payload = append([]byte(nil), payload...)
+		maskBytes(key, payload)
+	}
+	if c.writeTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	bufs := net.Buffers{hdr, payload}
+	_, err := bufs.WriteTo(c.conn)
+	return err
+}
+
+// Close 发送正常关闭帧并关闭连接
+func (c *Conn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// CloseWithCode 发送指定状态码的关闭帧并关闭连接，已经发送过关闭帧时只关闭连接
+func (c *Conn) CloseWithCode(code int, text string) error {
+	return c.closeWith(code, text)
+}
+
+// closeWith 发送关闭帧并关闭底层连接，code 为 0 时发送没有状态码的关闭帧
+func (c *Conn) closeWith(code int, text string) error {
+	var payload []byte
+	if code != 0 {
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+		if len(text) > maxControlPayload-2 {
+			text = text[:maxControlPayload-2]
+		}
+		payload = append(payload, text...)
+	}
+	// 缩短写超时，避免被阻塞中的写入长时间占用锁
+	_ = c.conn.SetWriteDeadline(time.Now().Add(closeTimeout))
+	var err error
+	c.writeMtx.Lock()
+	if !c.closeSent {
+		c.closeSent = true
+		err = c.writeFrame(CloseMessage, false, payload)
+	}
+	c.writeMtx.Unlock()
+
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		if cerr := c.conn.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// keepalive 定时发送 ping，连接关闭后退出
+func (c *Conn) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if err := c.WritePing(nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
@@ -0,0 +1,277 @@
+package websocket
+
+import (
+	"sync"
+)
+
+// DefaultSendBuffer 每个连接默认的发送队列长度
+const DefaultSendBuffer = 64
+
+// HubOptions Hub 配置
+type HubOptions struct {
+	// SendBuffer 每个连接的发送队列长度，默认 DefaultSendBuffer
+	// 队列满时连接会以 CloseTryAgainLater 关闭，避免慢连接拖慢广播
+	SendBuffer int
+}
+
+type message struct {
+	typ  int
+	data []byte
+}
+
+// Client 注册到 Hub 的连接，消息通过独立的 goroutine 按顺序写入
+type Client struct {
+	hub   *Hub
+	conn  *Conn
+	send  chan message
+	rooms map[string]struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// Hub 管理连接和房间，向房间广播消息
+//
+//	hub := websocket.NewHub(websocket.HubOptions{})
+//	mux.GET("/chat/{room}", func(w http.ResponseWriter, r *http.Request) {
+//		conn, err := upgrader.Upgrade(w, r, nil)
+//		if err != nil {
+//			return
+//		}
+//		client := hub.Register(conn)
+//		defer client.Close()
+//		room := r.PathValue("room")
+//		client.Join(room)
+//		for {
+//			typ, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			hub.Broadcast(room, typ, msg)
+//		}
+//	})
+type Hub struct {
+	opts    HubOptions
+	mtx     sync.RWMutex
+	closed  bool
+	clients map[*Client]struct{}
+	rooms   map[string]map[*Client]struct{}
+}
+
+// NewHub 创建 Hub
+func NewHub(opts HubOptions) *Hub {
+	if opts.SendBuffer <= 0 {
+		opts.SendBuffer = DefaultSendBuffer
+	}
+	return &Hub{
+		opts:    opts,
+		clients: make(map[*Client]struct{}),
+		rooms:   make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Register 注册连接，Hub 已关闭时返回的 Client 已经关闭
+func (h *Hub) Register(conn *Conn) *Client {
+	c := &Client{
+		hub:   h,
+		conn:  conn,
+		send:  make(chan message, h.opts.SendBuffer),
+		rooms: make(map[string]struct{}),
+		done:  make(chan struct{}),
+	}
+	h.mtx.Lock()
+	closed := h.closed
+	if !closed {
+		h.clients[c] = struct{}{}
+	}
+	h.mtx.Unlock()
+	if closed {
+		c.closeWith(CloseGoingAway, ErrHubClosed)
+		return c
+	}
+	go c.writeLoop()
+	return c
+}
+
+// Broadcast 向房间内的所有连接发送消息，room 为空时发送给所有连接
+// 不会阻塞，发送队列已满的连接会被关闭
+func (h *Hub) Broadcast(room string, messageType int, data []byte) {
+	msg := message{typ: messageType, data: data}
+	var slow []*Client
+	h.mtx.RLock()
+	clients := h.clients
+	if room != "" {
+		clients = h.rooms[room]
+	}
+	for c := range clients {
+		if !c.enqueue(msg) {
+			slow = append(slow, c)
+		}
+	}
+	h.mtx.RUnlock()
+	for _, c := range slow {
+		go c.closeWith(CloseTryAgainLater, ErrSlowConsumer)
+	}
+}
+
+// Clients 返回房间内的连接数量，room 为空时返回所有连接数量
+func (h *Hub) Clients(room string) int {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+	if room == "" {
+		return len(h.clients)
+	}
+	return len(h.rooms[room])
+}
+
+// Close 关闭 Hub 和所有连接
+func (h *Hub) Close() {
+	h.mtx.Lock()
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mtx.Unlock()
+	for _, c := range clients {
+		c.closeWith(CloseGoingAway, ErrHubClosed)
+	}
+}
+
+func (h *Hub) unregister(c *Client) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	delete(h.clients, c)
+	for room := range c.rooms {
+		h.leave(c, room)
+	}
+}
+
+// leave 调用方需要持有锁
+func (h *Hub) leave(c *Client, room string) {
+	delete(c.rooms, room)
+	clients := h.rooms[room]
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Conn 返回连接
+func (c *Client) Conn() *Conn {
+	return c.conn
+}
+
+// Join 加入房间
+func (c *Client) Join(room string) {
+	h := c.hub
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	clients, ok := h.rooms[room]
+	if !ok {
+		clients = make(map[*Client]struct{})
+		h.rooms[room] = clients
+	}
+	clients[c] = struct{}{}
+	c.rooms[room] = struct{}{}
+}
+
+// Leave 离开房间
+func (c *Client) Leave(room string) {
+	h := c.hub
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.leave(c, room)
+}
+
+// Rooms 返回已加入的房间
+func (c *Client) Rooms() []string {
+	h := c.hub
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Send 将消息放入发送队列，不会阻塞
+// 队列已满时关闭连接并返回 ErrSlowConsumer，连接已关闭时返回关闭原因
+func (c *Client) Send(messageType int, data []byte) error {
+	if c.enqueue(message{typ: messageType, data: data}) {
+		return nil
+	}
+	select {
+	case <-c.done:
+		return c.err
+	default:
+	}
+	go c.closeWith(CloseTryAgainLater, ErrSlowConsumer)
+	return ErrSlowConsumer
+}
+
+// Done 连接关闭后关闭
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err 返回连接被 Hub 关闭的原因，连接未关闭或正常关闭时返回 nil
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Close 从 Hub 移除并正常关闭连接
+func (c *Client) Close() error {
+	c.closeWith(CloseNormalClosure, nil)
+	return nil
+}
+
+func (c *Client) enqueue(msg message) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Client) closeWith(code int, err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		c.hub.unregister(c)
+		_ = c.conn.CloseWithCode(code, "")
+	})
+}
+
+func (c *Client) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.conn.Done():
+			c.closeWith(CloseNormalClosure, nil)
+			return
+		case msg := <-c.send:
+			if err := c.conn.WriteMessage(msg.typ, msg.data); err != nil {
+				c.closeWith(CloseGoingAway, err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package websocket
+
+import (
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fengjx/xin/middleware"
+)
+
+const (
+	// DefaultMaxMessageSize 默认最大消息大小
+	DefaultMaxMessageSize = 1 << 20
+	// DefaultWriteTimeout 默认写超时
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrader 将 http 请求升级为 WebSocket 连接
+type Upgrader struct {
+	// Subprotocols 服务端支持的子协议，按优先级排列
+	Subprotocols []string
+	// CheckOrigin 校验 Origin 请求头，为 nil 时只允许同源请求或没有 Origin 的请求
+	// 可以使用 CheckOriginCors 复用跨域配置
+	CheckOrigin func(r *http.Request) bool
+	// EnableCompression 启用 permessage-deflate 压缩，需要客户端同时支持
+	EnableCompression bool
+	// CompressionLevel 压缩级别，默认 flate.BestSpeed
+	CompressionLevel int
+	// MaxMessageSize 最大消息大小，压缩消息按解压后的大小计算，默认 DefaultMaxMessageSize，小于 0 表示不限制
+	MaxMessageSize int64
+	// PingInterval 发送 ping 的间隔，0 表示不发送
+	PingInterval time.Duration
+	// PongTimeout 发送 ping 后等待对端响应的时间，默认与 PingInterval 相同
+	// 超过 PingInterval + PongTimeout 没有收到任何帧时 ReadMessage 返回超时错误
+	PongTimeout time.Duration
+	// WriteTimeout 写超时，默认 DefaultWriteTimeout，小于 0 表示不限制
+	WriteTimeout time.Duration
+}
+
+// CheckOriginCors 使用跨域配置校验 Origin，与 middleware.CorsHandler 的规则一致
+// 没有 Origin 请求头时视为同源请求
+func CheckOriginCors(options middleware.CorsOptions) func(r *http.Request) bool {
+	c := middleware.NewCORS(options)
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		return c.OriginAllowed(r, origin)
+	}
+}
+
+// Upgrade 完成 WebSocket 握手，返回连接
+// 握手失败时已经写入错误响应，返回的错误为 ErrBadHandshake 或 ErrOriginNotAllowed
+// responseHeader 会添加到握手响应中，如 Set-Cookie
+// 经过 middleware.Logger、middleware.Compress 等中间件时仍然可以升级，记录的状态码为 101
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, u.fail(w, http.StatusMethodNotAllowed, ErrBadHandshake, "method not GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") {
+		return nil, u.fail(w, http.StatusBadRequest, ErrBadHandshake, "'upgrade' token not found in 'Connection' header")
+	}
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, u.fail(w, http.StatusBadRequest, ErrBadHandshake, "'websocket' token not found in 'Upgrade' header")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, u.fail(w, http.StatusUpgradeRequired, ErrBadHandshake, "unsupported version")
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, u.fail(w, http.StatusBadRequest, ErrBadHandshake, "invalid 'Sec-WebSocket-Key' header")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, u.fail(w, http.StatusForbidden, ErrOriginNotAllowed, r.Header.Get("Origin"))
+	}
+
+	subprotocol := u.selectSubprotocol(r)
+	compress := u.EnableCompression && parseDeflateOffer(r.Header.Values("Sec-Websocket-Extensions"))
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, u.fail(w, http.StatusInternalServerError, ErrBadHandshake, err.Error())
+	}
+	if brw.Reader.Buffered() > 0 {
+		netConn.Close()
+		return nil, fmt.Errorf("%w: client sent data before handshake is complete", ErrBadHandshake)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	sb.WriteString(computeAcceptKey(key))
+	sb.WriteString("\r\n")
+	if subprotocol != "" {
+		sb.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		sb.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	for k, vs := range responseHeader {
+		if k == "Sec-Websocket-Protocol" || k == "Sec-Websocket-Extensions" {
+			continue
+		}
+		for _, v := range vs {
+			sb.WriteString(k + ": " + strings.NewReplacer("\r", "", "\n", "").Replace(v) + "\r\n")
+		}
+	}
+	sb.WriteString("\r\n")
+
+	// 清除 http.Server 设置的读写超时
+	_ = netConn.SetDeadline(time.Time{})
+	if d := u.writeTimeout(); d > 0 {
+		_ = netConn.SetWriteDeadline(time.Now().Add(d))
+	}
+	if _, err = netConn.Write([]byte(sb.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	c := newConn(netConn, brw.Reader, false)
+	c.subprotocol = subprotocol
+	c.compress = compress
+	c.compressLevel = u.CompressionLevel
+	if c.compressLevel == 0 || c.compressLevel < flate.HuffmanOnly || c.compressLevel > flate.BestCompression {
+		c.compressLevel = flate.BestSpeed
+	}
+	c.maxMessageSize = u.MaxMessageSize
+	if c.maxMessageSize == 0 {
+		c.maxMessageSize = DefaultMaxMessageSize
+	}
+	c.writeTimeout = u.writeTimeout()
+	if u.PingInterval > 0 {
+		pongTimeout := u.PongTimeout
+		if pongTimeout <= 0 {
+			pongTimeout = u.PingInterval
+		}
+		c.readTimeout = u.PingInterval + pongTimeout
+		go c.keepalive(u.PingInterval)
+	}
+	return c, nil
+}
+
+func (u *Upgrader) writeTimeout() time.Duration {
+	switch {
+	case u.WriteTimeout < 0:
+		return 0
+	case u.WriteTimeout == 0:
+		return DefaultWriteTimeout
+	}
+	return u.WriteTimeout
+}
+
+func (u *Upgrader) fail(w http.ResponseWriter, status int, err error, reason string) error {
+	http.Error(w, http.StatusText(status), status)
+	return fmt.Errorf("%w: %s", err, reason)
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	if len(u.Subprotocols) == 0 {
+		return ""
+	}
+	var offered []string
+	for _, v := range r.Header.Values("Sec-Websocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			offered = append(offered, strings.TrimSpace(p))
+		}
+	}
+	for _, p := range u.Subprotocols {
+		for _, o := range offered {
+			if p == o {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// checkSameOrigin Origin 的 host 与请求的 Host 相同时返回 true
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func computeAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken 判断以逗号分隔的请求头中是否包含 token，忽略大小写
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, v := range header.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
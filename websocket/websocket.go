@@ -0,0 +1,103 @@
+// Package websocket 实现 RFC 6455 WebSocket 服务端，支持 permessage-deflate 压缩（RFC 7692）、
+// ping/pong 保活、消息大小限制和按房间广播
+//
+//	upgrader := &websocket.Upgrader{PingInterval: 30 * time.Second}
+//	mux.GET("/ws", func(w http.ResponseWriter, r *http.Request) {
+//		conn, err := upgrader.Upgrade(w, r, nil)
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		for {
+//			typ, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			conn.WriteMessage(typ, msg)
+//		}
+//	})
+package websocket
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 消息类型，与帧 opcode 一致
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// 关闭状态码，参考 RFC 6455 7.4.1
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseInternalServerErr       = 1011
+	CloseTryAgainLater           = 1013
+)
+
+var (
+	// ErrBadHandshake 请求不是合法的 WebSocket 握手请求
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrOriginNotAllowed Origin 校验失败
+	ErrOriginNotAllowed = errors.New("websocket: origin not allowed")
+	// ErrProtocol 对端违反协议，连接已经关闭
+	ErrProtocol = errors.New("websocket: protocol error")
+	// ErrMessageTooBig 消息超过大小限制，连接已经关闭
+	ErrMessageTooBig = errors.New("websocket: message too big")
+	// ErrCloseSent 已经发送关闭帧，不能再写入消息
+	ErrCloseSent = errors.New("websocket: close sent")
+	// ErrSlowConsumer 发送队列已满，连接被 Hub 关闭
+	ErrSlowConsumer = errors.New("websocket: slow consumer")
+	// ErrHubClosed Hub 已关闭
+	ErrHubClosed = errors.New("websocket: hub closed")
+)
+
+// CloseError 对端发送的关闭帧
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// IsCloseError 判断 err 是否为指定状态码的 *CloseError，codes 为空时只判断类型
+func IsCloseError(err error, codes ...int) bool {
+	var ce *CloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if ce.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// validCloseCode 判断对端发送的关闭状态码是否合法
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
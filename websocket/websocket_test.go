@@ -0,0 +1,377 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fengjx/xin"
+	"github.com/fengjx/xin/middleware"
+)
+
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+// dial 建立测试用的客户端连接
+func dial(t *testing.T, srv *httptest.Server, path string, header http.Header) (*Conn, *http.Response) {
+	t.Helper()
+	netConn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if err = req.Write(netConn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		netConn.Close()
+		return nil, resp
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+	c := newConn(netConn, br, true)
+	c.compress = strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	c.compressLevel = 1
+	t.Cleanup(func() { c.Close() })
+	return c, resp
+}
+
+func newEchoServer(t *testing.T, u *Upgrader, mws ...xin.HTTPMiddleware) *httptest.Server {
+	mux := xin.NewMux()
+	mux.Use(mws...)
+	mux.GET("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := u.Upgrade(w, r, http.Header{"X-Server": {"xin"}})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err = conn.WriteMessage(typ, msg); err != nil {
+				return
+			}
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEchoThroughMiddleware(t *testing.T) {
+	logs := &syncBuffer{}
+	// 最外层的中间件在日志写入后通知，连接结束后再检查日志
+	served := make(chan struct{}, 2)
+	srv := newEchoServer(t, &Upgrader{EnableCompression: true},
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer func() { served <- struct{}{} }()
+				next.ServeHTTP(w, r)
+			})
+		},
+		middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: log.New(logs, "", 0), NoColor: true}),
+		middleware.Compress(5),
+	)
+
+	for _, compress := range []bool{false, true} {
+		header := http.Header{}
+		if compress {
+			header.Set("Accept-Encoding", "gzip")
+			header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_max_window_bits")
+		}
+		c, resp := dial(t, srv, "/ws", header)
+		if c == nil {
+			t.Fatalf("upgrade failed: %d", resp.StatusCode)
+		}
+		if c.compress != compress {
+			t.Fatalf("expected compress %v, got %v", compress, c.compress)
+		}
+		if resp.Header.Get("X-Server") != "xin" {
+			t.Errorf("response header not sent")
+		}
+
+		large := bytes.Repeat([]byte("xin"), 30000)
+		messages := []struct {
+			typ  int
+			data []byte
+		}{
+			{TextMessage, []byte("hello 世界")},
+			{BinaryMessage, []byte{0, 1, 2}},
+			{BinaryMessage, large},
+			{TextMessage, nil},
+		}
+		for _, m := range messages {
+			if err := c.WriteMessage(m.typ, m.data); err != nil {
+				t.Fatal(err)
+			}
+			typ, data, err := c.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typ != m.typ || !bytes.Equal(data, m.data) {
+				t.Errorf("echo mismatch, type %d, len %d", typ, len(data))
+			}
+		}
+
+		// 分片消息和中间穿插的 ping
+		c.writeMtx.Lock()
+		_ = c.writeRawFrame(TextMessage, false, []byte("frag"))
+		_ = c.writeFrame(PingMessage, false, []byte("p"))
+		_ = c.writeRawFrame(continuationFrame, true, []byte("ment"))
+		c.writeMtx.Unlock()
+		typ, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 先收到 pong，读取时被跳过
+		if typ != TextMessage || string(data) != "fragment" {
+			t.Errorf("unexpected fragmented echo %d %q", typ, data)
+		}
+
+		c.Close()
+	}
+
+	for range 2 {
+		select {
+		case <-served:
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler not returned after close")
+		}
+	}
+	if !strings.Contains(logs.String(), " 101 ") {
+		t.Errorf("expected logger to record 101, got %q", logs.String())
+	}
+}
+
+// writeRawFrame 写入可以设置 FIN 的帧，调用方需要持有 writeMtx
+func (c *Conn) writeRawFrame(opcode int, fin bool, payload []byte) error {
+	key := [4]byte{1, 2, 3, 4}
+	hdr := []byte{byte(opcode), maskBit | byte(len(payload))}
+	if fin {
+		hdr[0] |= finalBit
+	}
+	hdr = append(hdr, key[:]...)
+	p := append([]byte(nil), payload...)
+	maskBytes(key, p)
+	_, err := c.conn.Write(append(hdr, p...))
+	return err
+}
+
+func TestMessageTooBig(t *testing.T) {
+	errCh := make(chan error, 1)
+	u := &Upgrader{MaxMessageSize: 16}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := u.Upgrade(w, r, nil)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		_, _, err = conn.ReadMessage()
+		errCh <- err
+	}))
+	defer srv.Close()
+
+	c, _ := dial(t, srv, "/", nil)
+	if err := c.WriteMessage(TextMessage, bytes.Repeat([]byte("a"), 17)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; !errors.Is(err, ErrMessageTooBig) {
+		t.Errorf("expected ErrMessageTooBig, got %v", err)
+	}
+	if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+		t.Errorf("expected close 1009, got %v", err)
+	}
+}
+
+func TestUpgradeRejected(t *testing.T) {
+	u := &Upgrader{
+		CheckOrigin: CheckOriginCors(middleware.CorsOptions{
+			AllowedOrigins: []string{"https://*.example.com"},
+		}),
+	}
+	srv := newEchoServer(t, u)
+	sameOrigin := newEchoServer(t, &Upgrader{})
+
+	tests := []struct {
+		name   string
+		srv    *httptest.Server
+		header http.Header
+		status int
+	}{
+		{"allowed origin", srv, http.Header{"Origin": {"https://app.example.com"}}, http.StatusSwitchingProtocols},
+		{"forbidden origin", srv, http.Header{"Origin": {"https://evil.com"}}, http.StatusForbidden},
+		{"same origin", sameOrigin, http.Header{"Origin": {sameOrigin.URL}}, http.StatusSwitchingProtocols},
+		{"cross origin", sameOrigin, http.Header{"Origin": {"https://evil.com"}}, http.StatusForbidden},
+		{"bad version", srv, http.Header{"Sec-Websocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"bad key", srv, http.Header{"Sec-Websocket-Key": {"short"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := dial(t, tt.srv, "/ws", tt.header)
+			if resp.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	errCh := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_, _, err = conn.ReadMessage()
+		errCh <- err
+	}))
+	defer srv.Close()
+
+	c, _ := dial(t, srv, "/", nil)
+	// 客户端帧没有掩码
+	c.isClient = false
+	if err := c.WriteMessage(TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	c.isClient = true
+	if err := <-errCh; !errors.Is(err, ErrProtocol) {
+		t.Errorf("expected ErrProtocol, got %v", err)
+	}
+	if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseProtocolError) {
+		t.Errorf("expected close 1002, got %v", err)
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub(HubOptions{})
+	defer hub.Close()
+	u := &Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := u.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := hub.Register(conn)
+		defer client.Close()
+		room := r.URL.Query().Get("room")
+		client.Join(room)
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			hub.Broadcast(room, typ, msg)
+		}
+	}))
+	defer srv.Close()
+
+	a, _ := dial(t, srv, "/?room=go", nil)
+	b, _ := dial(t, srv, "/?room=go", nil)
+	other, _ := dial(t, srv, "/?room=rust", nil)
+	waitFor(t, func() bool { return hub.Clients("go") == 2 && hub.Clients("") == 3 })
+
+	if err := a.WriteMessage(TextMessage, []byte("hi gophers")); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*Conn{a, b} {
+		if _, msg, err := c.ReadMessage(); err != nil || string(msg) != "hi gophers" {
+			t.Errorf("unexpected broadcast %q %v", msg, err)
+		}
+	}
+	hub.Broadcast("", TextMessage, []byte("all"))
+	if _, msg, err := other.ReadMessage(); err != nil || string(msg) != "all" {
+		t.Errorf("unexpected broadcast %q %v", msg, err)
+	}
+
+	b.Close()
+	waitFor(t, func() bool { return hub.Clients("go") == 1 })
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	hub := NewHub(HubOptions{SendBuffer: 1})
+	server, client := net.Pipe()
+	defer client.Close()
+	c := hub.Register(newConn(server, nil, false))
+	c.Join("room")
+
+	// net.Pipe 没有缓冲，客户端不读取时写入会一直阻塞
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = c.Send(TextMessage, []byte("msg"))
+	}
+	if !errors.Is(err, ErrSlowConsumer) {
+		t.Fatalf("expected ErrSlowConsumer, got %v", err)
+	}
+	waitFor(t, func() bool { return hub.Clients("room") == 0 })
+	if !errors.Is(c.Err(), ErrSlowConsumer) {
+		t.Errorf("expected client err ErrSlowConsumer, got %v", c.Err())
+	}
+}
+
+func TestParseDeflateOffer(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"permessage-deflate", true},
+		{"permessage-deflate; client_max_window_bits", true},
+		{"x-webkit-deflate-frame, permessage-deflate; server_no_context_takeover", true},
+		{"permessage-deflate; server_max_window_bits=10", false},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate", true},
+		{"permessage-deflate; unknown", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := parseDeflateOffer([]string{tt.header}); got != tt.want {
+			t.Errorf("parseDeflateOffer(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met")
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
-	"log"
 	"net"
 	"net/http"
 	"strconv"
@@ -18,8 +17,10 @@ import (
 
 var Debug = false
 
+// SetDebug 设置调试模式，同时设置全局默认日志实例是否启用调试日志
 func SetDebug(debug bool) {
 	Debug = debug
+	defaultLogger.SetDebug(debug)
 }
 
 // Xin 是核心Web服务器结构体，用于管理HTTP路由和服务器操作
@@ -211,11 +212,29 @@ func (x *Xin) StaticFS(pattern string, fs fs.FS) *Xin {
 	return x
 }
 
+// StaticFSWith 注册静态文件服务，参考 Mux.StaticFSWith
+func (x *Xin) StaticFSWith(pattern string, fs fs.FS, opts StaticOptions) *Xin {
+	x.router.StaticFSWith(pattern, fs, opts)
+	return x
+}
+
+// StaticWritable 注册可写的静态文件服务，参考 Mux.StaticWritable
+func (x *Xin) StaticWritable(pattern string, root string, opts WritableOptions) *Xin {
+	x.router.StaticWritable(pattern, root, opts)
+	return x
+}
+
+// SPA 注册单页应用，参考 Mux.SPA
+func (x *Xin) SPA(pattern string, fs fs.FS, opts SPAOptions) *Xin {
+	x.router.SPA(pattern, fs, opts)
+	return x
+}
+
 // HostPort 获取服务器地址和端口
 func (x *Xin) HostPort() (host string, port int) {
 	return x.host, x.port
 }
 
 func (x *Xin) defaultRecoverHandle(err any, stack *errs.Stack) {
-	log.Printf("panic: %s %+v\r\n", err, stack)
+	defaultLogger.Errorf("panic: %s %+v", err, stack)
 }